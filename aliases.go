@@ -24,6 +24,44 @@ type Feature = api.Feature
 
 var ErrQueueFull = bucketing.ErrQueueFull
 
+// Aliases to support controlling time
+type Clock = api.Clock
+type Ticker = api.Ticker
+type SystemClock = api.SystemClock
+
+// Aliases to support sticky bucketing
+type AssignmentStore = bucketing.AssignmentStore
+type InMemoryAssignmentStore = bucketing.InMemoryAssignmentStore
+type FileAssignmentStore = bucketing.FileAssignmentStore
+
+func NewInMemoryAssignmentStore() *InMemoryAssignmentStore {
+	return bucketing.NewInMemoryAssignmentStore()
+}
+
+func NewFileAssignmentStore(path string) (*FileAssignmentStore, error) {
+	return bucketing.NewFileAssignmentStore(path)
+}
+
+// Aliases to support handling filters this SDK doesn't support
+type UnknownFilterPolicy = bucketing.UnknownFilterPolicy
+type UnknownFilterOccurrence = bucketing.UnknownFilterOccurrence
+
+const (
+	UnknownFilterFailClosed = bucketing.UnknownFilterFailClosed
+	UnknownFilterFailOpen   = bucketing.UnknownFilterFailOpen
+	UnknownFilterReject     = bucketing.UnknownFilterReject
+)
+
+// Aliases to support partial config acceptance
+type QuarantinedFeature = bucketing.QuarantinedFeature
+
+// Aliases to support config introspection
+type FeatureDescriptor = bucketing.FeatureDescriptor
+type VariableDescriptor = bucketing.VariableDescriptor
+
+// Aliases to support monitoring bucketing key fallbacks
+type BucketingKeyFallbackCount = bucketing.BucketingKeyFallbackCount
+
 // Aliases to support customizing logging
 type Logger = util.Logger
 type DiscardLogger = util.DiscardLogger
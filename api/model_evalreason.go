@@ -11,8 +11,13 @@ const (
 	DefaultReasonMissingVariableForVariation DefaultReason = "Missing Variable for Variation"
 	DefaultReasonUserNotInRollout            DefaultReason = "User Not in Rollout"
 	DefaultReasonUserNotTargeted             DefaultReason = "User Not Targeted"
+	DefaultReasonPrerequisiteNotMet          DefaultReason = "Prerequisite Not Met"
+	DefaultReasonUserNotInLayerAllocation    DefaultReason = "User Not in Layer Allocation"
+	DefaultReasonUserInHoldout               DefaultReason = "User in Holdout"
+	DefaultReasonFeatureQuarantined          DefaultReason = "Feature Quarantined"
 	DefaultReasonInvalidVariableType         DefaultReason = "Invalid Variable Type"
 	DefaultReasonVariableTypeMismatch        DefaultReason = "Variable Type Mismatch"
+	DefaultReasonInvalidJSONValue            DefaultReason = "Invalid JSON Value"
 	DefaultReasonUnknown                     DefaultReason = "Unknown"
 	DefaultReasonError                       DefaultReason = "Error"
 	DefaultReasonNotDefaulted                DefaultReason = ""
@@ -24,4 +29,6 @@ const (
 	EvaluationReasonDefault        EvaluationReason = "DEFAULT"
 	EvaluationReasonDisabled       EvaluationReason = "DISABLED"
 	EvaluationReasonError          EvaluationReason = "ERROR"
+	EvaluationReasonHoldout        EvaluationReason = "HOLDOUT"
+	EvaluationReasonSticky         EvaluationReason = "STICKY"
 )
@@ -30,6 +30,10 @@ const (
 	ClientEventType_InternalSSEFailure         ClientEventType = "internalSSEFailure"
 	ClientEventType_InternalNewConfigAvailable ClientEventType = "internalNewConfigAvailable"
 	ClientEventType_InternalSSEConnected       ClientEventType = "internalSSEConnected"
+	// ClientEventType_UnknownFilters is sent when a config has filters this SDK doesn't support
+	ClientEventType_UnknownFilters ClientEventType = "unknownFilters"
+	// ClientEventType_FlagRegistryProblems is sent when a config doesn't match the flags of Options.FlagRegistry
+	ClientEventType_FlagRegistryProblems ClientEventType = "flagRegistryProblems"
 )
 
 type Event struct {
@@ -104,6 +108,7 @@ type EventQueueOptions struct {
 	FlushEventQueueSize          int           `json:"minEventsPerFlush,omitempty"`
 	EventRequestChunkSize        int           `json:"eventRequestChunkSize,omitempty"`
 	EventsAPIBasePath            string        `json:"eventsAPIBasePath,omitempty"`
+	Clock                        Clock         `json:"-"`
 }
 
 func (o *EventQueueOptions) CheckBounds() {
@@ -115,6 +120,9 @@ func (o *EventQueueOptions) CheckBounds() {
 	if o.EventsAPIBasePath == "" {
 		o.EventsAPIBasePath = "https://events.devcycle.com"
 	}
+	if o.Clock == nil {
+		o.Clock = SystemClock{}
+	}
 	if o.FlushEventQueueSize == 0 {
 		o.FlushEventQueueSize = 1000
 	} else if o.FlushEventQueueSize > 50000 {
@@ -8,9 +8,24 @@ type Project struct {
 }
 
 type ProjectSettings struct {
-	EdgeDB                     EdgeDBSettings `json:"edgeDB"`
-	OptIn                      OptInSettings  `json:"optIn"`
-	DisablePassthroughRollouts bool           `json:"disablePassthroughRollouts"`
+	EdgeDB                     EdgeDBSettings   `json:"edgeDB"`
+	OptIn                      OptInSettings    `json:"optIn"`
+	DisablePassthroughRollouts bool             `json:"disablePassthroughRollouts"`
+	Holdout                    *HoldoutSettings `json:"holdout,omitempty"`
+}
+
+// HoldoutSettings excludes a fixed percentage of users from every feature of the included types.
+// If no feature types are set, only experiments are included.
+type HoldoutSettings struct {
+	Percentage   float64  `json:"percentage" validate:"gte=0,lte=1"`
+	Salt         string   `json:"salt"`
+	FeatureTypes []string `json:"featureTypes"`
+	// ControlVariation is the key of the variation served to held out users, "control" if unset.
+	// Held out users of features without it are defaulted.
+	ControlVariation string `json:"controlVariation,omitempty"`
+	// BucketingKey is the custom data key users are held out by, falling back to the user id for
+	// users without it. Users are held out by user id if unset.
+	BucketingKey string `json:"bucketingKey,omitempty"`
 }
 
 type EdgeDBSettings struct {
@@ -33,6 +33,8 @@ type User struct {
 	PrivateCustomData map[string]interface{} `json:"privateCustomData,omitempty"`
 	// User's device model
 	DeviceModel string `json:"deviceModel,omitempty"`
+	// User's IP address, in IPv4 or IPv6 format
+	IP string `json:"ip,omitempty"`
 	// Date the user was created, Unix epoch timestamp format
 	LastSeenDate time.Time `json:"lastSeenDate,omitempty"`
 }
@@ -0,0 +1,75 @@
+package bucketing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// AssignmentVersion identifies the hashing used to assign keys to buckets. The bucket a key is
+// assigned to for a given salt and weights never changes within a version.
+type AssignmentVersion int
+
+const (
+	// AssignmentV1 hashes keys the same way targets bucket users into variations, with the salt
+	// in place of the target id.
+	AssignmentV1 AssignmentVersion = 1
+)
+
+var ErrInvalidWeights = errors.New("weights must be non-negative with a positive sum")
+var ErrUnknownAssignmentVersion = errors.New("unknown assignment version")
+
+// Assign deterministically assigns a key to one of the buckets described by weights, returning
+// the bucket's index. Each bucket is assigned its weight's share of keys; keys are spread
+// independently for each salt. Assign always uses AssignmentV1.
+func Assign(key, salt string, weights []float64) (int, error) {
+	return AssignWithVersion(AssignmentV1, key, salt, weights)
+}
+
+// AssignWithVersion is Assign using the given version's hashing.
+func AssignWithVersion(version AssignmentVersion, key, salt string, weights []float64) (int, error) {
+	hash, err := AssignmentHashWithVersion(version, key, salt)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	last := -1
+	for i, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return 0, ErrInvalidWeights
+		}
+		if weight > 0 {
+			total += weight
+			last = i
+		}
+	}
+	if last < 0 {
+		return 0, ErrInvalidWeights
+	}
+
+	cumulative := 0.0
+	for i, weight := range weights[:last] {
+		cumulative += weight / total
+		if weight > 0 && hash < cumulative {
+			return i, nil
+		}
+	}
+	// The last weighted bucket also takes a hash of exactly 1, and any rounding error in the sum
+	return last, nil
+}
+
+// AssignmentHash returns the key's hash for the salt in [0, 1] using AssignmentV1, for callers
+// comparing it against a percentage directly.
+func AssignmentHash(key, salt string) float64 {
+	hash, _ := AssignmentHashWithVersion(AssignmentV1, key, salt)
+	return hash
+}
+
+// AssignmentHashWithVersion is AssignmentHash using the given version's hashing.
+func AssignmentHashWithVersion(version AssignmentVersion, key, salt string) (float64, error) {
+	switch version {
+	case AssignmentV1:
+		return generateBoundedHash(key, murmurhashV3(salt, baseSeed)), nil
+	}
+	return 0, fmt.Errorf("%w: %d", ErrUnknownAssignmentVersion, version)
+}
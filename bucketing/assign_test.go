@@ -0,0 +1,69 @@
+package bucketing
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAssign_GoldenVectors pins AssignmentV1. These values must never change, other systems
+// rely on keys staying in the same bucket across SDK versions.
+func TestAssign_GoldenVectors(t *testing.T) {
+	tests := []struct {
+		key, salt string
+		hash      float64
+		quarters  int
+		weighted  int
+	}{
+		{key: "user-1", salt: "target-1", hash: 0.551961219066745, quarters: 2, weighted: 3},
+		{key: "user-2", salt: "target-1", hash: 0.48716343135739759, quarters: 1, weighted: 2},
+		{key: "user-1", salt: "target-2", hash: 0.79013687809699606, quarters: 3, weighted: 3},
+		{key: "shard-17", salt: "canary", hash: 0.58554785735568682, quarters: 2, weighted: 3},
+		{key: "", salt: "", hash: 0.1872461175516355, quarters: 0, weighted: 2},
+		{key: "ümlaut", salt: "salt", hash: 0.66431451674185571, quarters: 2, weighted: 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.key, tt.salt), func(t *testing.T) {
+			require.Equal(t, tt.hash, AssignmentHash(tt.key, tt.salt))
+
+			bucket, err := Assign(tt.key, tt.salt, []float64{1, 1, 1, 1})
+			require.NoError(t, err)
+			require.Equal(t, tt.quarters, bucket)
+
+			bucket, err = Assign(tt.key, tt.salt, []float64{0.5, 0, 1.5, 2})
+			require.NoError(t, err)
+			require.Equal(t, tt.weighted, bucket)
+		})
+	}
+}
+
+func TestAssign_MatchesTargetBucketing(t *testing.T) {
+	target := Target{Distribution: []TargetDistribution{
+		{Variation: "c", Percentage: 0.2},
+		{Variation: "b", Percentage: 0.5},
+		{Variation: "a", Percentage: 0.3},
+	}}
+	for i := 0; i < 1000; i++ {
+		userId := fmt.Sprintf("user-%d", i)
+		hashes := generateBoundedHashes(userId, "target-id")
+		require.Equal(t, hashes.BucketingHash, AssignmentHash(userId, "target-id"))
+		require.Equal(t, hashes.RolloutHash, AssignmentHash(userId+"_rollout", "target-id"))
+
+		variation, _, err := target.DecideTargetVariation(hashes.BucketingHash)
+		require.NoError(t, err)
+		bucket, err := Assign(userId, "target-id", []float64{0.2, 0.5, 0.3})
+		require.NoError(t, err)
+		require.Equal(t, target.Distribution[bucket].Variation, variation)
+	}
+}
+
+func TestAssign_Errors(t *testing.T) {
+	for _, weights := range [][]float64{nil, {}, {0, 0}, {1, -1}, {math.NaN()}, {math.Inf(1)}} {
+		_, err := Assign("key", "salt", weights)
+		require.ErrorIs(t, err, ErrInvalidWeights, "%v", weights)
+	}
+	_, err := AssignWithVersion(AssignmentVersion(0), "key", "salt", []float64{1})
+	require.ErrorIs(t, err, ErrUnknownAssignmentVersion)
+}
@@ -0,0 +1,259 @@
+package bucketing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/devcyclehq/go-server-sdk/v2/util"
+)
+
+// An AssignmentStore persists the variation each user was bucketed into for a feature, so that
+// changes to a split target's distribution don't move users who were already exposed. Rollouts
+// aren't covered: raising a rollout's percentage never removes users from it, and lowering it
+// removes users whatever their assignment.
+type AssignmentStore interface {
+	// GetAssignment returns the stored variation id for the user and feature, and whether one exists.
+	GetAssignment(userId, featureId string) (variationId string, ok bool, err error)
+	PutAssignment(userId, featureId, variationId string) error
+}
+
+var assignmentStores = make(map[string]AssignmentStore)
+var assignmentStoreMutex = &sync.RWMutex{}
+
+// SetAssignmentStore sets the store used for sticky bucketing with the given sdk key. A nil store
+// disables sticky bucketing.
+func SetAssignmentStore(sdkKey string, store AssignmentStore) {
+	assignmentStoreMutex.Lock()
+	defer assignmentStoreMutex.Unlock()
+	if store == nil {
+		delete(assignmentStores, sdkKey)
+		return
+	}
+	assignmentStores[sdkKey] = store
+}
+
+func getAssignmentStore(sdkKey string) AssignmentStore {
+	assignmentStoreMutex.RLock()
+	defer assignmentStoreMutex.RUnlock()
+	return assignmentStores[sdkKey]
+}
+
+// decideVariation buckets the user into a variation of the feature. For split targets, a stored
+// assignment takes precedence over hashing as long as the target still serves the variation.
+func decideVariation(ctx *evaluationContext, feature *ConfigFeature, hashes targetAndHashes) (variation *Variation, isRandomDistrib bool, isSticky bool, err error) {
+	if !isStickyEligible(ctx, hashes) {
+		variation, isRandomDistrib, err = bucketUserForVariation(feature, hashes)
+		return variation, isRandomDistrib, false, err
+	}
+
+	variationId, ok, err := ctx.assignmentStore.GetAssignment(ctx.user.UserId, feature.Id)
+	if err != nil {
+		util.Warnf("Failed to get sticky assignment for feature %s: %s", feature.Key, err)
+	} else if ok && targetServesVariation(&hashes.Target, variationId) {
+		for _, v := range feature.Variations {
+			if v.Id == variationId {
+				return v, true, true, nil
+			}
+		}
+	}
+
+	// No assignment, or the assigned variation is no longer served, so the user is re-bucketed
+	variation, isRandomDistrib, err = bucketUserForVariation(feature, hashes)
+	return variation, isRandomDistrib, false, err
+}
+
+// storeAssignment stores the variation served to the user by a split target, unless it was served
+// from a stored assignment. Only evaluations that serve a variable store assignments, so users
+// aren't pinned to features they were never exposed to.
+func storeAssignment(ctx *evaluationContext, feature *ConfigFeature, hashes targetAndHashes, variation *Variation, isSticky bool) {
+	if isSticky || !ctx.storeAssignments || !isStickyEligible(ctx, hashes) {
+		return
+	}
+	if err := ctx.assignmentStore.PutAssignment(ctx.user.UserId, feature.Id, variation.Id); err != nil {
+		util.Warnf("Failed to store sticky assignment for feature %s: %s", feature.Key, err)
+	}
+}
+
+// isStickyEligible reports whether the user is bucketed by a target splitting users between
+// variations, and a store is available for their assignment.
+func isStickyEligible(ctx *evaluationContext, hashes targetAndHashes) bool {
+	return ctx.assignmentStore != nil && !hashes.IsHoldout && len(hashes.Target.Distribution) > 1
+}
+
+func targetServesVariation(target *Target, variationId string) bool {
+	for _, distribution := range target.Distribution {
+		if distribution.Variation == variationId && distribution.Percentage > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type assignmentKey struct {
+	userId    string
+	featureId string
+}
+
+// InMemoryAssignmentStore is an AssignmentStore that keeps assignments for the lifetime of the process.
+type InMemoryAssignmentStore struct {
+	assignments map[assignmentKey]string
+	mutex       sync.RWMutex
+}
+
+func NewInMemoryAssignmentStore() *InMemoryAssignmentStore {
+	return &InMemoryAssignmentStore{assignments: make(map[assignmentKey]string)}
+}
+
+func (s *InMemoryAssignmentStore) GetAssignment(userId, featureId string) (string, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	variationId, ok := s.assignments[assignmentKey{userId, featureId}]
+	return variationId, ok, nil
+}
+
+func (s *InMemoryAssignmentStore) PutAssignment(userId, featureId, variationId string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.assignments[assignmentKey{userId, featureId}] = variationId
+	return nil
+}
+
+// FileAssignmentStore is an AssignmentStore that persists assignments to a file, appending a line
+// for each new assignment. The file is compacted when the store is opened.
+type FileAssignmentStore struct {
+	path string
+	// assignments maps user id -> feature id -> variation id
+	assignments map[string]map[string]string
+	mutex       sync.RWMutex
+	// fileMutex orders appends to file, and is held while the assignment is added to the map so
+	// the file and map agree on the latest assignment
+	fileMutex sync.Mutex
+	file      *os.File
+}
+
+// fileAssignment is a line of a FileAssignmentStore's file.
+type fileAssignment struct {
+	UserId      string `json:"u"`
+	FeatureId   string `json:"f"`
+	VariationId string `json:"v"`
+}
+
+// NewFileAssignmentStore loads the assignments stored at path, which doesn't need to exist yet.
+// The store keeps the file open until it is closed.
+func NewFileAssignmentStore(path string) (*FileAssignmentStore, error) {
+	store := &FileAssignmentStore{
+		path:        path,
+		assignments: make(map[string]map[string]string),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var assignment fileAssignment
+		if err = json.Unmarshal(line, &assignment); err != nil {
+			// A partial last line is left by an interrupted append
+			if i == len(lines)-1 {
+				util.Warnf("Ignoring partially written assignment in %s", path)
+				break
+			}
+			return nil, fmt.Errorf("invalid assignment on line %d of %s: %w", i+1, path, err)
+		}
+		store.set(assignment)
+	}
+
+	if err = store.compact(); err != nil {
+		return nil, err
+	}
+	if store.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *FileAssignmentStore) GetAssignment(userId, featureId string) (string, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	variationId, ok := s.assignments[userId][featureId]
+	return variationId, ok, nil
+}
+
+// PutAssignment appends the assignment to the store's file. The assignment is only kept if the
+// append succeeds, so a failed write is retried by the next put.
+func (s *FileAssignmentStore) PutAssignment(userId, featureId, variationId string) error {
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+	if current, ok, _ := s.GetAssignment(userId, featureId); ok && current == variationId {
+		return nil
+	}
+	assignment := fileAssignment{UserId: userId, FeatureId: featureId, VariationId: variationId}
+	line, err := json.Marshal(assignment)
+	if err != nil {
+		return err
+	}
+	if s.file == nil {
+		return errors.New("assignment store is closed")
+	}
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.set(assignment)
+	s.mutex.Unlock()
+	return nil
+}
+
+// Close closes the store's file. Assignments can still be read, but no longer stored.
+func (s *FileAssignmentStore) Close() error {
+	s.fileMutex.Lock()
+	defer s.fileMutex.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileAssignmentStore) set(assignment fileAssignment) {
+	if _, ok := s.assignments[assignment.UserId]; !ok {
+		s.assignments[assignment.UserId] = make(map[string]string)
+	}
+	s.assignments[assignment.UserId][assignment.FeatureId] = assignment.VariationId
+}
+
+// compact writes the latest assignments to a temporary file and renames it over the store's file,
+// so that a failed write never leaves a partial file behind.
+func (s *FileAssignmentStore) compact() error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	for userId, features := range s.assignments {
+		for featureId, variationId := range features {
+			if err := encoder.Encode(fileAssignment{UserId: userId, FeatureId: featureId, VariationId: variationId}); err != nil {
+				return err
+			}
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,226 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// splitConfig builds a config JSON with an "experiment" feature whose single target splits users
+// between its "on" and "off" variations with the given percentage of "on".
+func splitConfig(t *testing.T, onPercentage float64) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "experiment", variation: "on"}}), &config))
+	target := config["features"].([]interface{})[0].(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+	target["distribution"] = []interface{}{
+		map[string]interface{}{"_variation": "on", "percentage": onPercentage},
+		map[string]interface{}{"_variation": "off", "percentage": 1 - onPercentage},
+	}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestVariableForUser_StickyAssignments(t *testing.T) {
+	sdkKey := "dvc_server_sticky"
+	store := NewInMemoryAssignmentStore()
+	SetAssignmentStore(sdkKey, store)
+	defer SetAssignmentStore(sdkKey, nil)
+	eventQueue, err := NewEventQueue(sdkKey, &api.EventQueueOptions{DisableAutomaticEventLogging: true}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer func() { _ = eventQueue.Close() }()
+
+	users := make([]api.PopulatedUser, 200)
+	for i := range users {
+		users[i] = api.User{UserId: fmt.Sprintf("user-%d", i)}.GetPopulatedUser(&api.PlatformData{})
+	}
+	variable := func(user api.PopulatedUser) (interface{}, api.EvaluationReason) {
+		_, value, _, evalReason, _, err := VariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, eventQueue, nil)
+		require.NoError(t, err)
+		return value, evalReason
+	}
+
+	require.NoError(t, SetConfig(splitConfig(t, 0.5), sdkKey, "", "", ""))
+	initial := make([]interface{}, len(users))
+	for i, user := range users {
+		value, evalReason := variable(user)
+		require.Equal(t, api.EvaluationReasonSplit, evalReason)
+		initial[i] = value
+	}
+
+	require.NoError(t, SetConfig(splitConfig(t, 0.1), sdkKey, "", "", ""))
+	for i, user := range users {
+		value, evalReason := variable(user)
+		require.Equal(t, api.EvaluationReasonSticky, evalReason)
+		require.Equal(t, initial[i], value, "user %s should keep their assignment", user.UserId)
+	}
+
+	// A variation the target no longer serves invalidates the assignment
+	require.NoError(t, store.PutAssignment(users[0].UserId, "experiment", "deleted"))
+	_, evalReason := variable(users[0])
+	require.Equal(t, api.EvaluationReasonSplit, evalReason)
+	variationId, ok, err := store.GetAssignment(users[0].UserId, "experiment")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotEqual(t, "deleted", variationId)
+
+	// Targets serving a single variation ignore stored assignments
+	config, err := getConfig(sdkKey)
+	require.NoError(t, err)
+	config.Features[0].Configuration.Targets[0].Distribution = []TargetDistribution{{Variation: "on", Percentage: 1}}
+	for _, user := range users {
+		value, evalReason := variable(user)
+		require.Equal(t, api.EvaluationReasonTargetingMatch, evalReason)
+		require.Equal(t, true, value)
+	}
+}
+
+func TestStickyAssignments_Previews(t *testing.T) {
+	sdkKey := "dvc_server_sticky_previews"
+	store := NewInMemoryAssignmentStore()
+	SetAssignmentStore(sdkKey, store)
+	defer SetAssignmentStore(sdkKey, nil)
+	require.NoError(t, SetConfig(splitConfig(t, 0.5), sdkKey, "", "", ""))
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	// Previews read assignments, but don't store them
+	_, _, _, evalReason, _, err := VariableForUserAt(sdkKey, user, "var-experiment", VariableTypesBool, nil, nil, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, api.EvaluationReasonSplit, evalReason)
+	_, err = EvaluateFeatures(sdkKey, user, nil, time.Now())
+	require.NoError(t, err)
+	_, err = GenerateBucketedConfig(sdkKey, user, nil)
+	require.NoError(t, err)
+	_, ok, err := store.GetAssignment(user.UserId, "experiment")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, _, _, _, err = VariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, nil, nil)
+	require.NoError(t, err)
+	_, ok, err = store.GetAssignment(user.UserId, "experiment")
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, _, _, evalReason, _, err = VariableForUserAt(sdkKey, user, "var-experiment", VariableTypesBool, nil, nil, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, api.EvaluationReasonSticky, evalReason)
+	evaluations, err := EvaluateFeatures(sdkKey, user, nil, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, api.EvaluationReasonSticky, evaluations[0].EvalReason)
+}
+
+// splitRolloutConfig builds a splitConfig whose target is rolled out to the given percentage of users.
+func splitRolloutConfig(t *testing.T, onPercentage, rolloutPercentage float64) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(splitConfig(t, onPercentage), &config))
+	target := config["features"].([]interface{})[0].(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+	target["rollout"] = Rollout{Type: "gradual", StartPercentage: rolloutPercentage, StartDate: time.Now().Add(-time.Hour)}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestVariableForUser_StickyAssignmentsWithRollout(t *testing.T) {
+	sdkKey := "dvc_server_sticky_rollout"
+	store := NewInMemoryAssignmentStore()
+	SetAssignmentStore(sdkKey, store)
+	defer SetAssignmentStore(sdkKey, nil)
+	eventQueue, err := NewEventQueue(sdkKey, &api.EventQueueOptions{DisableAutomaticEventLogging: true}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer func() { _ = eventQueue.Close() }()
+
+	users := make([]api.PopulatedUser, 200)
+	for i := range users {
+		users[i] = api.User{UserId: fmt.Sprintf("user-%d", i)}.GetPopulatedUser(&api.PlatformData{})
+	}
+	variable := func(user api.PopulatedUser) (interface{}, bool) {
+		_, value, _, _, _, err := VariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, eventQueue, nil)
+		if errors.Is(err, ErrUserDoesNotQualifyForTargets) {
+			return nil, false
+		}
+		require.NoError(t, err)
+		return value, true
+	}
+
+	require.NoError(t, SetConfig(splitRolloutConfig(t, 0.5, 0.3), sdkKey, "", "", ""))
+	initial := make([]interface{}, len(users))
+	rolledOut := make([]bool, len(users))
+	for i, user := range users {
+		initial[i], rolledOut[i] = variable(user)
+		_, stored, err := store.GetAssignment(user.UserId, "experiment")
+		require.NoError(t, err)
+		require.Equal(t, rolledOut[i], stored, "only users served the feature are assigned")
+	}
+
+	// Raising the rollout percentage keeps the users already rolled out in, with their assignment
+	require.NoError(t, SetConfig(splitRolloutConfig(t, 0.1, 0.6), sdkKey, "", "", ""))
+	for i, user := range users {
+		value, ok := variable(user)
+		if rolledOut[i] {
+			require.True(t, ok, "user %s should stay in the rollout", user.UserId)
+			require.Equal(t, initial[i], value, "user %s should keep their assignment", user.UserId)
+		}
+	}
+
+	// Lowering it rolls users back whatever their assignment
+	require.NoError(t, SetConfig(splitRolloutConfig(t, 0.5, 0), sdkKey, "", "", ""))
+	for _, user := range users {
+		_, ok := variable(user)
+		require.False(t, ok)
+	}
+}
+
+func TestFileAssignmentStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assignments.json")
+	store, err := NewFileAssignmentStore(path)
+	require.NoError(t, err)
+	_, ok, err := store.GetAssignment("user", "feature")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, store.PutAssignment("user", "feature", "old-variation"))
+	require.NoError(t, store.PutAssignment("user", "feature", "variation"))
+	require.NoError(t, store.PutAssignment("user", "other-feature", "other-variation"))
+	require.NoError(t, store.Close())
+
+	// A failed write isn't kept, so the assignment is written by the next put
+	require.Error(t, store.PutAssignment("user", "new-feature", "variation"))
+	_, ok, err = store.GetAssignment("user", "new-feature")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// An interrupted append leaves a partial last line, which is ignored
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"u":"user","f":"partial`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reloaded, err := NewFileAssignmentStore(path)
+	require.NoError(t, err)
+	defer func() { _ = reloaded.Close() }()
+	variationId, ok, err := reloaded.GetAssignment("user", "feature")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "variation", variationId)
+	variationId, _, err = reloaded.GetAssignment("user", "other-feature")
+	require.NoError(t, err)
+	require.Equal(t, "other-variation", variationId)
+
+	// Loading compacts the file to the latest assignments
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 2)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0o600))
+	_, err = NewFileAssignmentStore(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,197 @@
+package bucketing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devcyclehq/go-server-sdk/v2/util"
+)
+
+// AudienceReferenceProblem is an audienceMatch reference that can't be evaluated, either because
+// the audience doesn't exist or because it is part of a reference cycle.
+type AudienceReferenceProblem struct {
+	AudienceId string
+	// Cycle is set when the audience references itself, e.g. [a b a] for a -> b -> a
+	Cycle []string
+	// Features are the keys of the features whose targets depend on the audience
+	Features []string
+}
+
+func (p AudienceReferenceProblem) String() string {
+	if len(p.Cycle) > 0 {
+		return fmt.Sprintf("audience reference cycle %s (features: %s)", strings.Join(p.Cycle, " -> "), strings.Join(p.Features, ", "))
+	}
+	return fmt.Sprintf("missing audience %s (features: %s)", p.AudienceId, strings.Join(p.Features, ", "))
+}
+
+// AudienceReferenceError is returned when a config contains audienceMatch filters that can't be
+// resolved for one or more features.
+type AudienceReferenceError struct {
+	Problems []AudienceReferenceProblem
+}
+
+func (e *AudienceReferenceError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.String()
+	}
+	return "invalid audience references: " + strings.Join(problems, "; ")
+}
+
+// Features returns the keys of every feature affected by a problem.
+func (e *AudienceReferenceError) Features() []string {
+	seen := make(map[string]bool)
+	var features []string
+	for _, problem := range e.Problems {
+		for _, feature := range problem.Features {
+			if !seen[feature] {
+				seen[feature] = true
+				features = append(features, feature)
+			}
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+func collectAudienceReferences(f FilterOrOperator, references []string) []string {
+	switch filter := f.(type) {
+	case *AudienceOperator:
+		if filter != nil {
+			for _, nested := range filter.Filters {
+				references = collectAudienceReferences(nested, references)
+			}
+		}
+	case AudienceOperator:
+		for _, nested := range filter.Filters {
+			references = collectAudienceReferences(nested, references)
+		}
+	case *AudienceMatchFilter:
+		references = append(references, filter.Audiences...)
+	}
+	return references
+}
+
+// validateAudienceReferences resolves every audienceMatch reference in the config. Missing
+// audiences and reference cycles are returned as an AudienceReferenceError if any feature depends
+// on them, and only logged otherwise.
+func validateAudienceReferences(config *configBody) error {
+	graph := make(map[string][]string, len(config.Audiences))
+	for id, audience := range config.Audiences {
+		if audience.Filters != nil {
+			graph[id] = collectAudienceReferences(audience.Filters, nil)
+		}
+	}
+
+	problems := findAudienceReferenceProblems(config.Audiences, graph)
+	problemIndex := make(map[string][]int)
+	for i, problem := range problems {
+		if len(problem.Cycle) == 0 {
+			problemIndex[problem.AudienceId] = append(problemIndex[problem.AudienceId], i)
+			continue
+		}
+		for _, id := range problem.Cycle[:len(problem.Cycle)-1] {
+			problemIndex[id] = append(problemIndex[id], i)
+		}
+	}
+
+	for _, feature := range config.Features {
+		var references []string
+		for _, target := range feature.Configuration.Targets {
+			if target.Audience != nil && target.Audience.Filters != nil {
+				references = collectAudienceReferences(target.Audience.Filters, references)
+			}
+		}
+		affected := make(map[int]bool)
+		visited := make(map[string]bool)
+		for len(references) > 0 {
+			id := references[len(references)-1]
+			references = references[:len(references)-1]
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			if _, ok := config.Audiences[id]; !ok && len(problemIndex[id]) == 0 {
+				// Referenced directly by a target, rather than through another audience
+				problems = append(problems, AudienceReferenceProblem{AudienceId: id})
+				problemIndex[id] = []int{len(problems) - 1}
+			}
+			for _, i := range problemIndex[id] {
+				affected[i] = true
+			}
+			references = append(references, graph[id]...)
+		}
+		for i := range affected {
+			problems[i].Features = append(problems[i].Features, feature.Key)
+		}
+	}
+
+	var featureProblems []AudienceReferenceProblem
+	for _, problem := range problems {
+		if len(problem.Features) == 0 {
+			util.Warnf("Ignoring unused %s", problem)
+			continue
+		}
+		sort.Strings(problem.Features)
+		featureProblems = append(featureProblems, problem)
+	}
+	if len(featureProblems) == 0 {
+		return nil
+	}
+	return &AudienceReferenceError{Problems: featureProblems}
+}
+
+// findAudienceReferenceProblems returns one problem per missing audience and per reference cycle,
+// in a deterministic order.
+func findAudienceReferenceProblems(audiences map[string]NoIdAudience, graph map[string][]string) []AudienceReferenceProblem {
+	ids := make([]string, 0, len(graph))
+	for id := range graph {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(graph))
+	missing := make(map[string]bool)
+	var problems []AudienceReferenceProblem
+	var path []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = inProgress
+		path = append(path, id)
+		for _, reference := range graph[id] {
+			if _, ok := audiences[reference]; !ok {
+				if !missing[reference] {
+					missing[reference] = true
+					problems = append(problems, AudienceReferenceProblem{AudienceId: reference})
+				}
+				continue
+			}
+			switch state[reference] {
+			case unvisited:
+				visit(reference)
+			case inProgress:
+				start := len(path) - 1
+				for path[start] != reference {
+					start--
+				}
+				cycle := append(append([]string{}, path[start:]...), reference)
+				problems = append(problems, AudienceReferenceProblem{AudienceId: reference, Cycle: cycle})
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return problems
+}
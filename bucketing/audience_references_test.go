@@ -0,0 +1,132 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// audienceReferenceConfig builds a config JSON where each feature has a single target matching the
+// given audiences, and each audience matches the audiences it references.
+func audienceReferenceConfig(t *testing.T, audiences map[string][]string, features map[string][]string) []byte {
+	audienceMatch := func(ids []string) map[string]interface{} {
+		return map[string]interface{}{
+			"operator": "and",
+			"filters": []interface{}{
+				map[string]interface{}{"type": "audienceMatch", "comparator": "=", "_audiences": ids},
+			},
+		}
+	}
+	configAudiences := map[string]interface{}{}
+	for id, references := range audiences {
+		if len(references) == 0 {
+			configAudiences[id] = map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{map[string]interface{}{"type": "all"}}}}
+			continue
+		}
+		configAudiences[id] = map[string]interface{}{"filters": audienceMatch(references)}
+	}
+	var configFeatures []interface{}
+	for key, references := range features {
+		configFeatures = append(configFeatures, map[string]interface{}{
+			"_id":        "id-" + key,
+			"key":        key,
+			"type":       "release",
+			"variations": []interface{}{map[string]interface{}{"_id": "variation", "key": "variation", "variables": []interface{}{}}},
+			"configuration": map[string]interface{}{
+				"_id": "config-" + key,
+				"targets": []interface{}{map[string]interface{}{
+					"_id":          fmt.Sprintf("target-%s", key),
+					"_audience":    map[string]interface{}{"_id": "audience", "filters": audienceMatch(references)},
+					"distribution": []interface{}{map[string]interface{}{"_variation": "variation", "percentage": 1}},
+				}},
+			},
+		})
+	}
+	config, err := json.Marshal(map[string]interface{}{
+		"project":     map[string]interface{}{"_id": "project", "key": "project", "a0_organization": "org", "settings": map[string]interface{}{}},
+		"environment": map[string]interface{}{"_id": "environment", "key": "environment"},
+		"audiences":   configAudiences,
+		"features":    configFeatures,
+		"variables":   []interface{}{},
+	})
+	require.NoError(t, err)
+	return config
+}
+
+func TestNewConfig_AudienceReferences(t *testing.T) {
+	testCases := []struct {
+		name             string
+		audiences        map[string][]string
+		features         map[string][]string
+		expectedProblems []AudienceReferenceProblem
+	}{
+		{
+			name:      "valid references",
+			audiences: map[string][]string{"a": {"b"}, "b": nil},
+			features:  map[string][]string{"feature-1": {"a"}, "feature-2": {"b"}},
+		},
+		{
+			name:      "self reference",
+			audiences: map[string][]string{"a": {"a"}},
+			features:  map[string][]string{"feature-1": {"a"}},
+			expectedProblems: []AudienceReferenceProblem{
+				{AudienceId: "a", Cycle: []string{"a", "a"}, Features: []string{"feature-1"}},
+			},
+		},
+		{
+			name:      "indirect cycle",
+			audiences: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}, "d": {"b"}},
+			features:  map[string][]string{"feature-1": {"d"}, "feature-2": {"a"}},
+			expectedProblems: []AudienceReferenceProblem{
+				{AudienceId: "a", Cycle: []string{"a", "b", "c", "a"}, Features: []string{"feature-1", "feature-2"}},
+			},
+		},
+		{
+			name:      "missing audience referenced by an audience",
+			audiences: map[string][]string{"a": {"missing"}},
+			features:  map[string][]string{"feature-1": {"a"}, "feature-2": nil},
+			expectedProblems: []AudienceReferenceProblem{
+				{AudienceId: "missing", Features: []string{"feature-1"}},
+			},
+		},
+		{
+			name:      "missing audience referenced by a target",
+			audiences: map[string][]string{},
+			features:  map[string][]string{"feature-1": {"missing"}},
+			expectedProblems: []AudienceReferenceProblem{
+				{AudienceId: "missing", Features: []string{"feature-1"}},
+			},
+		},
+		{
+			name:      "unused broken audiences are ignored",
+			audiences: map[string][]string{"a": {"a"}, "b": {"missing"}, "c": nil},
+			features:  map[string][]string{"feature-1": {"c"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := newConfig(audienceReferenceConfig(t, tc.audiences, tc.features), "", "", "")
+			if len(tc.expectedProblems) == 0 {
+				require.NoError(t, err)
+				require.NotNil(t, config)
+				return
+			}
+			require.Error(t, err)
+			var referenceErr *AudienceReferenceError
+			require.ErrorAs(t, err, &referenceErr)
+			require.Equal(t, tc.expectedProblems, referenceErr.Problems)
+		})
+	}
+}
+
+func TestAudienceReferenceError_Error(t *testing.T) {
+	err := &AudienceReferenceError{Problems: []AudienceReferenceProblem{
+		{AudienceId: "a", Cycle: []string{"a", "b", "a"}, Features: []string{"feature-2", "feature-1"}},
+		{AudienceId: "missing", Features: []string{"feature-1"}},
+	}}
+	require.Equal(t, "invalid audience references: audience reference cycle a -> b -> a (features: feature-2, feature-1); missing audience missing (features: feature-1)", err.Error())
+	require.Equal(t, []string{"feature-1", "feature-2"}, err.Features())
+}
@@ -2,7 +2,6 @@ package bucketing
 
 import (
 	"errors"
-	"strconv"
 	"time"
 
 	"github.com/devcyclehq/go-server-sdk/v2/api"
@@ -14,6 +13,8 @@ var allEvalReasons = []api.EvaluationReason{
 	api.EvaluationReasonSplit,
 	api.EvaluationReasonDefault,
 	api.EvaluationReasonError,
+	api.EvaluationReasonHoldout,
+	api.EvaluationReasonSticky,
 }
 
 // Max value of an unsigned 32-bit integer, which is what murmurhash returns
@@ -28,7 +29,12 @@ var ErrMissingVariation = errors.New("config missing variation")
 var ErrFailedToDecideVariation = errors.New("failed to decide target variation")
 var ErrUserRollout = errors.New("user does not qualify for feature rollout")
 var ErrUserDoesNotQualifyForTargets = errors.New("user does not qualify for any targets for feature")
+var ErrPrerequisiteNotMet = errors.New("user does not meet the prerequisites for feature")
+var ErrUserNotInLayerAllocation = errors.New("user is not in the layer allocation for feature")
+var ErrUserInHoldout = errors.New("user is held out of feature")
+var ErrFeatureQuarantined = errors.New("feature for variable was quarantined")
 var ErrInvalidVariableType = errors.New("invalid variable type")
+var ErrInvalidJSONValue = errors.New("variable value failed validation")
 var ErrConfigMissing = errors.New("no config available")
 
 type boundedHashType struct {
@@ -50,36 +56,13 @@ func generateBoundedHash(input string, hashSeed uint32) float64 {
 	return float64(mh) / float64(maxHashValue)
 }
 
-func determineUserBucketingValueForTarget(targetBucketingKey, userId string, mergedCustomData map[string]interface{}) string {
-	if targetBucketingKey == "" || targetBucketingKey == "user_id" {
-		return userId
-	}
-
-	if customDataValue, keyExists := mergedCustomData[targetBucketingKey]; keyExists {
-		if customDataValue == nil {
-			return defaultBucketingValue
-		}
-
-		switch v := customDataValue.(type) {
-		case int:
-			return strconv.Itoa(v)
-		case float64:
-			return strconv.FormatFloat(v, 'f', -1, 64)
-		case string:
-			return v
-		case bool:
-			return strconv.FormatBool(v)
-		default:
-			return defaultBucketingValue
-		}
-	}
-	return defaultBucketingValue
-}
-
 func getCurrentRolloutPercentage(rollout Rollout, currentDate time.Time) float64 {
 	var start = rollout.StartPercentage
 	var startDateTime = rollout.StartDate
 	var currentDateTime = currentDate
+	if rollout.EndDate != nil && !currentDateTime.Before(*rollout.EndDate) {
+		return 0
+	}
 	if rollout.Type == "schedule" {
 		if currentDateTime.After(startDateTime) {
 			return 1
@@ -134,57 +117,80 @@ func getCurrentRolloutPercentage(rollout Rollout, currentDate time.Time) float64
 	return (currentStage.Percentage + (nextStage.Percentage - currentStage.Percentage)) * currentDatePercentage
 }
 
-func isUserInRollout(rollout Rollout, boundedHash float64) bool {
-	var rolloutPercentage = getCurrentRolloutPercentage(rollout, time.Now())
+func isUserInRollout(rollout Rollout, boundedHash float64, currentDate time.Time) bool {
+	var rolloutPercentage = getCurrentRolloutPercentage(rollout, currentDate)
 	return rolloutPercentage != 0 && (boundedHash <= rolloutPercentage)
 }
 
-func evaluateSegmentationForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, clientCustomData map[string]interface{}) (t *Target, isRollout bool) {
-	var mergedCustomData = user.CombinedCustomData()
+func evaluateSegmentationForFeature(config *configBody, feature *ConfigFeature, ctx *evaluationContext) (t *Target, isRollout bool, isHoldout bool) {
+	holdout := config.Project.Settings.Holdout
+	if holdoutIncludes(holdout, feature) && isUserInHoldout(holdout, holdoutBucketingValue(holdout, ctx)) {
+		return holdoutTarget(holdout, feature), false, true
+	}
 	for _, target := range feature.Configuration.Targets {
+		trace := TargetTrace{TargetId: target.Id}
+		if !target.ActiveWindow.contains(ctx.now) {
+			ctx.traceTarget(feature, target, trace)
+			continue
+		}
+		trace.Active = true
 		passthroughEnabled := !config.Project.Settings.DisablePassthroughRollouts
 		rolloutCriteriaMet := true
 		if target.Rollout != nil && passthroughEnabled {
 
-			var bucketingValue = determineUserBucketingValueForTarget(target.BucketingKey, user.UserId, mergedCustomData)
+			var bucketingValue = determineUserBucketingValueForTarget(target, ctx.user.UserId, ctx.mergedCustomData)
 
 			boundedHash := generateBoundedHashes(bucketingValue, target.Id)
 			rolloutHash := boundedHash.RolloutHash
-			rolloutCriteriaMet = isUserInRollout(*target.Rollout, rolloutHash)
+			rolloutCriteriaMet = isUserInRollout(*target.Rollout, rolloutHash, ctx.now)
 			isRollout = rolloutCriteriaMet
+			trace.Rollout = &RolloutTrace{Hash: rolloutHash, InRollout: rolloutCriteriaMet}
 		}
-		operator := target.Audience.Filters
-		if rolloutCriteriaMet && operator.Evaluate(config.Audiences, user, clientCustomData) {
-			return target, isRollout
+		if rolloutCriteriaMet && config.targetMatchesAudience(target, ctx) {
+			trace.AudienceMatched = true
+			ctx.traceTarget(feature, target, trace)
+			return target, isRollout, false
 		}
+		ctx.traceTarget(feature, target, trace)
 	}
-	return nil, false
+	return nil, false, false
 }
 
 type targetAndHashes struct {
-	Target Target
-	Hashes boundedHashType
+	Target    Target
+	Hashes    boundedHashType
+	IsHoldout bool
 }
 
-func doesUserQualifyForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, clientCustomData map[string]interface{}) (targetAndHashes, bool, error) {
-	target, isRollout := evaluateSegmentationForFeature(config, feature, user, clientCustomData)
+func doesUserQualifyForFeature(config *configBody, feature *ConfigFeature, ctx *evaluationContext) (targetAndHashes, bool, error) {
+	if !userInLayerAllocation(config, feature, ctx) {
+		return targetAndHashes{}, false, ErrUserNotInLayerAllocation
+	}
+	target, isRollout, isHoldout := evaluateSegmentationForFeature(config, feature, ctx)
+	if isHoldout && target == nil {
+		return targetAndHashes{}, false, ErrUserInHoldout
+	}
 	if target == nil {
+		if !prerequisitesMet(config, feature, ctx) {
+			return targetAndHashes{}, isRollout, ErrPrerequisiteNotMet
+		}
 		return targetAndHashes{}, isRollout, ErrUserDoesNotQualifyForTargets
 	}
 
-	var mergedCustomData = user.CombinedCustomData()
-	var bucketingValue = determineUserBucketingValueForTarget(target.BucketingKey, user.UserId, mergedCustomData)
+	bucketingValue, source := resolveBucketingValue(target, ctx.user.UserId, ctx.mergedCustomData)
+	config.countBucketingKeySource(target, source)
 
 	boundedHashes := generateBoundedHashes(bucketingValue, target.Id)
 	rolloutHash := boundedHashes.RolloutHash
 	passthroughEnabled := !config.Project.Settings.DisablePassthroughRollouts
 
-	if target.Rollout != nil && !passthroughEnabled && !isUserInRollout(*target.Rollout, rolloutHash) {
+	if target.Rollout != nil && !passthroughEnabled && !isUserInRollout(*target.Rollout, rolloutHash, ctx.now) {
 		return targetAndHashes{}, true, ErrUserRollout
 	}
 	return targetAndHashes{
-		Target: *target,
-		Hashes: boundedHashes,
+		Target:    *target,
+		Hashes:    boundedHashes,
+		IsHoldout: isHoldout,
 	}, isRollout, nil
 }
 
@@ -202,22 +208,29 @@ func bucketUserForVariation(feature *ConfigFeature, hashes targetAndHashes) (*Va
 }
 
 func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomData map[string]interface{}) (*api.BucketedUserConfig, error) {
+	return GenerateBucketedConfigAt(sdkKey, user, clientCustomData, now(sdkKey))
+}
+
+// GenerateBucketedConfigAt generates the bucketed config for the user as of the given time.
+func GenerateBucketedConfigAt(sdkKey string, user api.PopulatedUser, clientCustomData map[string]interface{}, at time.Time) (*api.BucketedUserConfig, error) {
 	config, err := getConfig(sdkKey)
 	if err != nil {
 		return nil, err
 	}
+	ctx := newEvaluationContext(user, clientCustomData, at)
+	ctx.assignmentStore = getAssignmentStore(sdkKey)
 	variableMap := make(map[string]api.ReadOnlyVariable)
 	featureKeyMap := make(map[string]api.Feature)
 	featureVariationMap := make(map[string]string)
 	variableVariationMap := make(map[string]api.FeatureVariation)
 
 	for _, feature := range config.Features {
-		thash, _, err := doesUserQualifyForFeature(config, feature, user, clientCustomData)
+		thash, _, err := doesUserQualifyForFeature(config, feature, ctx)
 		if err != nil {
 			continue
 		}
 
-		variation, _, err := bucketUserForVariation(feature, thash)
+		variation, _, isSticky, err := decideVariation(ctx, feature, thash)
 		if err != nil {
 			return nil, err
 		}
@@ -230,6 +243,12 @@ func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomD
 			VariationName: variation.Name,
 		}
 		featureVariationMap[feature.Id] = variation.Id
+		evalReason := api.EvaluationReasonTargetingMatch
+		if thash.IsHoldout {
+			evalReason = api.EvaluationReasonHoldout
+		} else if isSticky {
+			evalReason = api.EvaluationReasonSticky
+		}
 
 		for _, variationVar := range variation.Variables {
 			variable := config.GetVariableForId(variationVar.Var)
@@ -247,7 +266,7 @@ func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomD
 					Type_: variable.Type,
 					Value: variationVar.Value,
 					Eval: api.EvalDetails{
-						Reason:  api.EvaluationReasonTargetingMatch,
+						Reason:  evalReason,
 						Details: "",
 					},
 				},
@@ -268,32 +287,59 @@ func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomD
 }
 
 func VariableForUser(sdkKey string, user api.PopulatedUser, variableKey string, expectedVariableType string, eventQueue *EventQueue, clientCustomData map[string]interface{}) (variableType string, variableValue any, featureId string, evalReason api.EvaluationReason, evalDetails string, err error) {
-	variableType, variableValue, featureId, variationId, evalReason, err := generateBucketedVariableForUser(sdkKey, user, variableKey, clientCustomData)
+	return variableForUser(sdkKey, user, variableKey, expectedVariableType, eventQueue, clientCustomData, now(sdkKey), true, nil)
+}
+
+// ValueValidator checks a variable's value before its evaluation is reported.
+type ValueValidator func(value interface{}) error
+
+// ValidatedVariableForUser is VariableForUser with the variable's value checked by validate
+// before the evaluation is reported. Values that fail validation are defaulted with
+// ErrInvalidJSONValue.
+func ValidatedVariableForUser(sdkKey string, user api.PopulatedUser, variableKey string, expectedVariableType string, eventQueue *EventQueue, clientCustomData map[string]interface{}, validate ValueValidator) (variableType string, variableValue any, featureId string, evalReason api.EvaluationReason, evalDetails string, err error) {
+	return variableForUser(sdkKey, user, variableKey, expectedVariableType, eventQueue, clientCustomData, now(sdkKey), true, validate)
+}
+
+// VariableForUserAt previews the evaluation of the variable for the user as of the given time.
+// Events are only queued if an event queue is provided, and sticky assignments are read but
+// never stored.
+func VariableForUserAt(sdkKey string, user api.PopulatedUser, variableKey string, expectedVariableType string, eventQueue *EventQueue, clientCustomData map[string]interface{}, at time.Time) (variableType string, variableValue any, featureId string, evalReason api.EvaluationReason, evalDetails string, err error) {
+	return variableForUser(sdkKey, user, variableKey, expectedVariableType, eventQueue, clientCustomData, at, false, nil)
+}
+
+func variableForUser(sdkKey string, user api.PopulatedUser, variableKey string, expectedVariableType string, eventQueue *EventQueue, clientCustomData map[string]interface{}, at time.Time, storeAssignments bool, validate ValueValidator) (variableType string, variableValue any, featureId string, evalReason api.EvaluationReason, evalDetails string, err error) {
+	variableType, variableValue, featureId, variationId, evalReason, err := generateBucketedVariableForUser(sdkKey, user, variableKey, clientCustomData, at, storeAssignments, validate)
 	if err != nil {
-		eventErr := eventQueue.QueueVariableDefaultedEvent(variableKey, BucketResultErrorToDefaultReason(err))
-		if eventErr != nil {
-			util.Warnf("Failed to queue variable defaulted event: %s", eventErr)
-		}
+		queueVariableDefaultedEvent(eventQueue, variableKey, err)
 		return "", nil, "", evalReason, string(BucketResultErrorToDefaultReason(err)), err
 	}
 
 	if !isVariableTypeValid(variableType, expectedVariableType) && expectedVariableType != "" {
 		err = ErrInvalidVariableType
-		eventErr := eventQueue.QueueVariableDefaultedEvent(variableKey, BucketResultErrorToDefaultReason(err))
-		if eventErr != nil {
-			util.Warnf("Failed to queue variable defaulted event: %s", eventErr)
-		}
+		queueVariableDefaultedEvent(eventQueue, variableKey, err)
 		return "", nil, "", evalReason, string(BucketResultErrorToDefaultReason(err)), err
 	}
 
-	eventErr := eventQueue.QueueVariableEvaluatedEvent(variableKey, featureId, variationId, evalReason)
-	if eventErr != nil {
-		util.Warnf("Failed to queue variable evaluated event: %s", eventErr)
+	if eventQueue != nil {
+		eventErr := eventQueue.QueueVariableEvaluatedEvent(variableKey, featureId, variationId, evalReason)
+		if eventErr != nil {
+			util.Warnf("Failed to queue variable evaluated event: %s", eventErr)
+		}
 	}
 
 	return variableType, variableValue, featureId, evalReason, string(BucketResultErrorToDefaultReason(err)), err
 }
 
+func queueVariableDefaultedEvent(eventQueue *EventQueue, variableKey string, err error) {
+	if eventQueue == nil {
+		return
+	}
+	eventErr := eventQueue.QueueVariableDefaultedEvent(variableKey, BucketResultErrorToDefaultReason(err))
+	if eventErr != nil {
+		util.Warnf("Failed to queue variable defaulted event: %s", eventErr)
+	}
+}
+
 func isVariableTypeValid(variableType string, expectedVariableType string) bool {
 	if variableType != VariableTypesString &&
 		variableType != VariableTypesNumber &&
@@ -307,7 +353,7 @@ func isVariableTypeValid(variableType string, expectedVariableType string) bool
 	return true
 }
 
-func generateBucketedVariableForUser(sdkKey string, user api.PopulatedUser, key string, clientCustomData map[string]interface{}) (variableType string, variableValue any, featureId string, variationId string, evalReason api.EvaluationReason, err error) {
+func generateBucketedVariableForUser(sdkKey string, user api.PopulatedUser, key string, clientCustomData map[string]interface{}, at time.Time, storeAssignments bool, validate ValueValidator) (variableType string, variableValue any, featureId string, variationId string, evalReason api.EvaluationReason, err error) {
 	config, err := getConfig(sdkKey)
 	if err != nil {
 		util.Warnf("Variable called before client initialized, returning default value")
@@ -319,16 +365,21 @@ func generateBucketedVariableForUser(sdkKey string, user api.PopulatedUser, key
 		return "", nil, "", "", api.EvaluationReasonDisabled, err
 	}
 	featForVariable := config.GetFeatureForVariableId(variable.Id)
+	if _, ok := config.quarantinedVariables[variable.Id]; ok && featForVariable == nil {
+		return "", nil, "", "", api.EvaluationReasonDefault, ErrFeatureQuarantined
+	}
 	if featForVariable == nil {
 		err = ErrMissingFeature
 		return "", nil, "", "", api.EvaluationReasonDisabled, err
 	}
 
-	targetHashes, isRollout, err := doesUserQualifyForFeature(config, featForVariable, user, clientCustomData)
+	ctx := newEvaluationContext(user, clientCustomData, at)
+	ctx.assignmentStore = getAssignmentStore(sdkKey)
+	targetHashes, isRollout, err := doesUserQualifyForFeature(config, featForVariable, ctx)
 	if err != nil {
 		return "", nil, "", "", api.EvaluationReasonDefault, err
 	}
-	variation, isRandomDistrib, err := bucketUserForVariation(featForVariable, targetHashes)
+	variation, isRandomDistrib, isSticky, err := decideVariation(ctx, featForVariable, targetHashes)
 	if err != nil {
 		return "", nil, "", "", api.EvaluationReasonDefault, err
 	}
@@ -337,10 +388,30 @@ func generateBucketedVariableForUser(sdkKey string, user api.PopulatedUser, key
 		err = ErrMissingVariableForVariation
 		return "", nil, "", "", api.EvaluationReasonDisabled, err
 	}
-	if isRollout || isRandomDistrib {
-		return variable.Type, variationVariable.Value, featForVariable.Id, variation.Id, api.EvaluationReasonSplit, nil
+	if validate != nil {
+		if validationErr := validate(variationVariable.Value); validationErr != nil {
+			util.Warnf("Defaulting variable %s: %s", key, validationErr)
+			return "", nil, "", "", api.EvaluationReasonDefault, ErrInvalidJSONValue
+		}
+	}
+	ctx.storeAssignments = storeAssignments
+	storeAssignment(ctx, featForVariable, targetHashes, variation, isSticky)
+	reason := evaluationReason(targetHashes, isRollout, isRandomDistrib, isSticky)
+	return variable.Type, variationVariable.Value, featForVariable.Id, variation.Id, reason, nil
+}
+
+// evaluationReason returns the reason a user bucketed into a feature was served its variation.
+func evaluationReason(targetHashes targetAndHashes, isRollout, isRandomDistrib, isSticky bool) api.EvaluationReason {
+	switch {
+	case targetHashes.IsHoldout:
+		return api.EvaluationReasonHoldout
+	case isSticky:
+		return api.EvaluationReasonSticky
+	case isRollout || isRandomDistrib:
+		return api.EvaluationReasonSplit
+	default:
+		return api.EvaluationReasonTargetingMatch
 	}
-	return variable.Type, variationVariable.Value, featForVariable.Id, variation.Id, api.EvaluationReasonTargetingMatch, nil
 }
 
 func BucketResultErrorToDefaultReason(err error) (defaultReason api.DefaultReason) {
@@ -359,8 +430,18 @@ func BucketResultErrorToDefaultReason(err error) (defaultReason api.DefaultReaso
 		return api.DefaultReasonUserNotInRollout
 	case ErrUserDoesNotQualifyForTargets:
 		return api.DefaultReasonUserNotTargeted
+	case ErrPrerequisiteNotMet:
+		return api.DefaultReasonPrerequisiteNotMet
+	case ErrUserNotInLayerAllocation:
+		return api.DefaultReasonUserNotInLayerAllocation
+	case ErrUserInHoldout:
+		return api.DefaultReasonUserInHoldout
+	case ErrFeatureQuarantined:
+		return api.DefaultReasonFeatureQuarantined
 	case ErrInvalidVariableType:
 		return api.DefaultReasonInvalidVariableType
+	case ErrInvalidJSONValue:
+		return api.DefaultReasonInvalidJSONValue
 	case nil:
 		return api.DefaultReasonNotDefaulted
 	default:
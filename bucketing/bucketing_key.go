@@ -0,0 +1,162 @@
+package bucketing
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// compositeBucketingKeySeparator joins the values of a composite bucketing key
+const compositeBucketingKeySeparator = "|"
+
+// bucketingKeySource is where a user's bucketing value for a target came from.
+type bucketingKeySource int
+
+const (
+	bucketingKeySourcePrimary bucketingKeySource = iota
+	bucketingKeySourceFallback
+	bucketingKeySourceDefault
+)
+
+// BucketingKeyFallbackCount is how often users were bucketed into a target without a value for
+// its bucketing key, since the current config was set.
+type BucketingKeyFallbackCount struct {
+	FeatureKey string `json:"featureKey"`
+	TargetId   string `json:"targetId"`
+	// Fallback counts users bucketed by one of the target's BucketingKeyFallbacks
+	Fallback int64 `json:"fallback"`
+	// Default counts users bucketed by the constant default value, as no key had a value
+	Default int64 `json:"default"`
+}
+
+type bucketingKeyCounts struct {
+	feature  *ConfigFeature
+	fallback atomic.Int64
+	dflt     atomic.Int64
+}
+
+// GetBucketingKeyFallbackCounts returns the counts of the targets of the config set with the given
+// sdk key that have fallen back from their bucketing key, ordered by feature and target.
+func GetBucketingKeyFallbackCounts(sdkKey string) []BucketingKeyFallbackCount {
+	config, err := getConfig(sdkKey)
+	if err != nil {
+		return nil
+	}
+	var counts []BucketingKeyFallbackCount
+	for target, count := range config.bucketingKeyCounts {
+		fallback, dflt := count.fallback.Load(), count.dflt.Load()
+		if fallback == 0 && dflt == 0 {
+			continue
+		}
+		counts = append(counts, BucketingKeyFallbackCount{
+			FeatureKey: count.feature.Key,
+			TargetId:   target.Id,
+			Fallback:   fallback,
+			Default:    dflt,
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].FeatureKey != counts[j].FeatureKey {
+			return counts[i].FeatureKey < counts[j].FeatureKey
+		}
+		return counts[i].TargetId < counts[j].TargetId
+	})
+	return counts
+}
+
+func (c *configBody) countBucketingKeySource(target *Target, source bucketingKeySource) {
+	count, ok := c.bucketingKeyCounts[target]
+	if !ok {
+		return
+	}
+	switch source {
+	case bucketingKeySourceFallback:
+		count.fallback.Add(1)
+	case bucketingKeySourceDefault:
+		count.dflt.Add(1)
+	}
+}
+
+// compileBucketingKeys parses the paths of the target's custom data bucketing keys.
+func (t *Target) compileBucketingKeys() {
+	t.bucketingKeyPaths = nil
+	keys := append(append([]string{t.BucketingKey}, t.BucketingKeys...), t.BucketingKeyFallbacks...)
+	for _, key := range keys {
+		if isUserIdBucketingKey(key) {
+			continue
+		}
+		if t.bucketingKeyPaths == nil {
+			t.bucketingKeyPaths = make(map[string][]string)
+		}
+		t.bucketingKeyPaths[key] = parseDataKeyPath(key)
+	}
+}
+
+func (t *Target) bucketingKeyPath(key string) []string {
+	if path, ok := t.bucketingKeyPaths[key]; ok {
+		return path
+	}
+	return parseDataKeyPath(key)
+}
+
+func isUserIdBucketingKey(key string) bool {
+	return key == "" || key == "user_id"
+}
+
+func determineUserBucketingValueForTarget(target *Target, userId string, mergedCustomData map[string]interface{}) string {
+	value, _ := resolveBucketingValue(target, userId, mergedCustomData)
+	return value
+}
+
+// resolveBucketingValue returns the user's value for the target's bucketing key, or composite
+// bucketing keys, then for the first of its fallbacks the user has a value for. Users without
+// a value for any of them are bucketed by defaultBucketingValue.
+func resolveBucketingValue(target *Target, userId string, mergedCustomData map[string]interface{}) (string, bucketingKeySource) {
+	if len(target.BucketingKeys) > 0 {
+		values := make([]string, len(target.BucketingKeys))
+		composite := true
+		for i, key := range target.BucketingKeys {
+			var ok bool
+			if values[i], ok = bucketingValueForKey(target, key, userId, mergedCustomData); !ok {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return strings.Join(values, compositeBucketingKeySeparator), bucketingKeySourcePrimary
+		}
+	} else if value, ok := bucketingValueForKey(target, target.BucketingKey, userId, mergedCustomData); ok {
+		return value, bucketingKeySourcePrimary
+	}
+
+	for _, key := range target.BucketingKeyFallbacks {
+		if value, ok := bucketingValueForKey(target, key, userId, mergedCustomData); ok {
+			return value, bucketingKeySourceFallback
+		}
+	}
+	return defaultBucketingValue, bucketingKeySourceDefault
+}
+
+// bucketingValueForKey returns the user's value for a bucketing key, and whether they have one.
+// Null values and values that aren't strings, numbers or booleans count as missing.
+func bucketingValueForKey(target *Target, key, userId string, mergedCustomData map[string]interface{}) (string, bool) {
+	if isUserIdBucketingKey(key) {
+		return userId, true
+	}
+	customDataValue, keyExists := lookupCustomDataPath(mergedCustomData, key, target.bucketingKeyPath(key))
+	if !keyExists {
+		return "", false
+	}
+	switch v := customDataValue.(type) {
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
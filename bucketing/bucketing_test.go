@@ -2,6 +2,8 @@ package bucketing
 
 import (
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -53,7 +55,7 @@ func TestBucketingFirstMatchingTarget(t *testing.T) {
 				Country: "Canada",
 			}.GetPopulatedUser(&api.PlatformData{})
 
-			target, isRollout, err := doesUserQualifyForFeature(config, feature, user, nil)
+			target, isRollout, err := doesUserQualifyForFeature(config, feature, newEvaluationContext(user, nil, time.Now()))
 			require.False(t, isRollout)
 			require.NoError(t, err)
 
@@ -61,7 +63,7 @@ func TestBucketingFirstMatchingTarget(t *testing.T) {
 			require.Equal(t, target.Target.Id, "61536f468fd67f0091982533")
 
 			user.Email = "test@email.com"
-			target, isRollout, err = doesUserQualifyForFeature(config, feature, user, nil)
+			target, isRollout, err = doesUserQualifyForFeature(config, feature, newEvaluationContext(user, nil, time.Now()))
 			require.False(t, isRollout)
 			require.NoError(t, err)
 
@@ -95,12 +97,12 @@ func TestBucketing_RolloutGatesUser(t *testing.T) {
 		},
 	}
 
-	_, _, err = doesUserQualifyForFeature(config, feature, user, nil)
+	_, _, err = doesUserQualifyForFeature(config, feature, newEvaluationContext(user, nil, time.Now()))
 	require.Error(t, err)
 	require.Equal(t, ErrUserRollout, err)
 
 	user.UserId = "pass_rollout"
-	target, _, err := doesUserQualifyForFeature(config, feature, user, nil)
+	target, _, err := doesUserQualifyForFeature(config, feature, newEvaluationContext(user, nil, time.Now()))
 	require.NoError(t, err)
 	require.Equal(t, "61536f468fd67f0091982533", target.Target.Id)
 }
@@ -135,7 +137,7 @@ func TestBucketing_RolloutPassthroughUser(t *testing.T) {
 		},
 	}
 
-	target, isRollout, err := doesUserQualifyForFeature(config, feature, user, nil)
+	target, isRollout, err := doesUserQualifyForFeature(config, feature, newEvaluationContext(user, nil, time.Now()))
 	require.NoError(t, err)
 	require.False(t, isRollout)
 	require.Equal(t, "61536f669c69b86cccc5f15e", target.Target.Id)
@@ -154,7 +156,7 @@ func TestBucketing_RolloutPassthroughUser(t *testing.T) {
 		},
 	}
 
-	target, isRollout, err = doesUserQualifyForFeature(config, feature, user, nil)
+	target, isRollout, err = doesUserQualifyForFeature(config, feature, newEvaluationContext(user, nil, time.Now()))
 	require.NoError(t, err)
 	require.True(t, isRollout)
 	require.Equal(t, "61536f468fd67f0091982533", target.Target.Id)
@@ -324,43 +326,46 @@ func TestConfigParsing(t *testing.T) {
 	}
 }
 
+// rolloutTestTime is the time rollouts are evaluated at in the rollout tests
+var rolloutTestTime = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
 func TestRollout_Gradual(t *testing.T) {
 	rollout := Rollout{
 		Type:            "gradual",
 		StartPercentage: 0,
-		StartDate:       time.Now().Add(time.Hour * -24),
+		StartDate:       rolloutTestTime.Add(time.Hour * -24),
 		Stages: []RolloutStage{
 			{
 				Type:       "linear",
-				Date:       time.Now().Add(time.Hour * 24),
+				Date:       rolloutTestTime.Add(time.Hour * 24),
 				Percentage: 1,
 			},
 		},
 	}
-	if !isUserInRollout(rollout, 0.35) {
+	if !isUserInRollout(rollout, 0.35, rolloutTestTime) {
 		t.Errorf("User should pass rollout - 0.35")
 	}
-	if isUserInRollout(rollout, 0.85) {
+	if isUserInRollout(rollout, 0.85, rolloutTestTime) {
 		t.Errorf("User should not pass rollout - 0.85")
 	}
-	if !isUserInRollout(rollout, 0.2) {
+	if !isUserInRollout(rollout, 0.2, rolloutTestTime) {
 		t.Errorf("User should pass rollout - 0.2")
 	}
-	if isUserInRollout(rollout, 0.75) {
+	if isUserInRollout(rollout, 0.75, rolloutTestTime) {
 		t.Errorf("User should not pass rollout - 0.75")
 	}
 	t.Log("Changing rollout percentage to 0.8")
 	rollout.Stages[0].Percentage = 0.8
 
-	if isUserInRollout(rollout, 0.51) {
+	if isUserInRollout(rollout, 0.51, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.51")
 	}
 
-	if isUserInRollout(rollout, 0.95) {
+	if isUserInRollout(rollout, 0.95, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.95")
 	}
 
-	if !isUserInRollout(rollout, 0.35) {
+	if !isUserInRollout(rollout, 0.35, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.35")
 	}
 }
@@ -369,29 +374,29 @@ func TestRollout_Gradual_WithStartDateFuture(t *testing.T) {
 	rollout := Rollout{
 		Type:            "gradual",
 		StartPercentage: 0,
-		StartDate:       time.Now().Add(time.Hour * 24),
+		StartDate:       rolloutTestTime.Add(time.Hour * 24),
 		Stages: []RolloutStage{
 			{
 				Type:       "linear",
-				Date:       time.Now().Add(time.Hour * 48),
+				Date:       rolloutTestTime.Add(time.Hour * 48),
 				Percentage: 1,
 			},
 		},
 	}
 
-	if isUserInRollout(rollout, 0) {
+	if isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0")
 	}
-	if isUserInRollout(rollout, 0.25) {
+	if isUserInRollout(rollout, 0.25, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.25")
 	}
-	if isUserInRollout(rollout, 0.5) {
+	if isUserInRollout(rollout, 0.5, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.5")
 	}
-	if isUserInRollout(rollout, 0.75) {
+	if isUserInRollout(rollout, 0.75, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.75")
 	}
-	if isUserInRollout(rollout, 1) {
+	if isUserInRollout(rollout, 1, rolloutTestTime) {
 		t.Error("User should not pass rollout - 1")
 	}
 }
@@ -400,23 +405,23 @@ func TestRollout_Gradual_WithStartDate_NoEnd(t *testing.T) {
 	rollout := Rollout{
 		Type:            "gradual",
 		StartPercentage: 1,
-		StartDate:       time.Now().Add(time.Hour * -24),
+		StartDate:       rolloutTestTime.Add(time.Hour * -24),
 		Stages:          []RolloutStage{},
 	}
 
-	if !isUserInRollout(rollout, 0) {
+	if !isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should pass rollout - 0")
 	}
-	if !isUserInRollout(rollout, 0.25) {
+	if !isUserInRollout(rollout, 0.25, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.25")
 	}
-	if !isUserInRollout(rollout, 0.5) {
+	if !isUserInRollout(rollout, 0.5, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.5")
 	}
-	if !isUserInRollout(rollout, 0.75) {
+	if !isUserInRollout(rollout, 0.75, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.75")
 	}
-	if !isUserInRollout(rollout, 1) {
+	if !isUserInRollout(rollout, 1, rolloutTestTime) {
 		t.Error("User should pass rollout - 1")
 	}
 }
@@ -425,23 +430,23 @@ func TestRollout_Gradual_WithStartDate_NoEnd_Future(t *testing.T) {
 	rollout := Rollout{
 		Type:            "gradual",
 		StartPercentage: 0,
-		StartDate:       time.Now().Add(time.Hour * 24),
+		StartDate:       rolloutTestTime.Add(time.Hour * 24),
 		Stages:          []RolloutStage{},
 	}
 
-	if isUserInRollout(rollout, 0) {
+	if isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0")
 	}
-	if isUserInRollout(rollout, 0.25) {
+	if isUserInRollout(rollout, 0.25, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.25")
 	}
-	if isUserInRollout(rollout, 0.5) {
+	if isUserInRollout(rollout, 0.5, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.5")
 	}
-	if isUserInRollout(rollout, 0.75) {
+	if isUserInRollout(rollout, 0.75, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.75")
 	}
-	if isUserInRollout(rollout, 1) {
+	if isUserInRollout(rollout, 1, rolloutTestTime) {
 		t.Error("User should not pass rollout - 1")
 	}
 }
@@ -449,22 +454,22 @@ func TestRollout_Gradual_WithStartDate_NoEnd_Future(t *testing.T) {
 func TestRollout_Schedule_Valid(t *testing.T) {
 	rollout := Rollout{
 		Type:      "schedule",
-		StartDate: time.Now().Add(time.Minute * -1),
+		StartDate: rolloutTestTime.Add(time.Minute * -1),
 	}
 
-	if !isUserInRollout(rollout, 0) {
+	if !isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should pass rollout - 0")
 	}
-	if !isUserInRollout(rollout, 0.25) {
+	if !isUserInRollout(rollout, 0.25, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.25")
 	}
-	if !isUserInRollout(rollout, 0.5) {
+	if !isUserInRollout(rollout, 0.5, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.5")
 	}
-	if !isUserInRollout(rollout, 0.75) {
+	if !isUserInRollout(rollout, 0.75, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.75")
 	}
-	if !isUserInRollout(rollout, 1) {
+	if !isUserInRollout(rollout, 1, rolloutTestTime) {
 		t.Error("User should pass rollout - 1")
 	}
 }
@@ -473,22 +478,22 @@ func TestRollout_Schedule_Future(t *testing.T) {
 	rollout := Rollout{
 		Type: "schedule",
 
-		StartDate: time.Now().Add(time.Minute * 1),
+		StartDate: rolloutTestTime.Add(time.Minute * 1),
 	}
 
-	if isUserInRollout(rollout, 0) {
+	if isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0")
 	}
-	if isUserInRollout(rollout, 0.25) {
+	if isUserInRollout(rollout, 0.25, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.25")
 	}
-	if isUserInRollout(rollout, 0.5) {
+	if isUserInRollout(rollout, 0.5, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.5")
 	}
-	if isUserInRollout(rollout, 0.75) {
+	if isUserInRollout(rollout, 0.75, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.75")
 	}
-	if isUserInRollout(rollout, 1) {
+	if isUserInRollout(rollout, 1, rolloutTestTime) {
 		t.Error("User should not pass rollout - 1")
 	}
 }
@@ -499,45 +504,45 @@ func TestRollout_Stepped_Valid(t *testing.T) {
 		Stages: []RolloutStage{
 			{
 				Type:       "discrete",
-				Date:       time.Now().Add(time.Hour * -48),
+				Date:       rolloutTestTime.Add(time.Hour * -48),
 				Percentage: 0.25,
 			},
 			{
 				Type:       "discrete",
-				Date:       time.Now().Add(time.Hour * -24),
+				Date:       rolloutTestTime.Add(time.Hour * -24),
 				Percentage: 0.5,
 			},
 			{
 				Type:       "discrete",
-				Date:       time.Now().Add(time.Hour * 24),
+				Date:       rolloutTestTime.Add(time.Hour * 24),
 				Percentage: 0.75,
 			},
 		},
 	}
 
-	if !isUserInRollout(rollout, 0) {
+	if !isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should pass rollout - 0")
 	}
-	if !isUserInRollout(rollout, 0.25) {
+	if !isUserInRollout(rollout, 0.25, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.25")
 	}
-	if !isUserInRollout(rollout, 0.4) {
+	if !isUserInRollout(rollout, 0.4, rolloutTestTime) {
 		t.Error("User should pass rollout - 0.4")
 	}
-	if isUserInRollout(rollout, 0.6) {
+	if isUserInRollout(rollout, 0.6, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.6")
 	}
-	if isUserInRollout(rollout, 0.9) {
+	if isUserInRollout(rollout, 0.9, rolloutTestTime) {
 		t.Error("User should not pass rollout - 0.9")
 	}
 }
 
 func TestRollout_Stepped_Error(t *testing.T) {
 	rollout := Rollout{}
-	if isUserInRollout(rollout, 0) {
+	if isUserInRollout(rollout, 0, rolloutTestTime) {
 		t.Error("User should not pass rollout - empty")
 	}
-	if isUserInRollout(rollout, 1) {
+	if isUserInRollout(rollout, 1, rolloutTestTime) {
 		t.Error("User should not pass rollout - empty")
 	}
 }
@@ -562,7 +567,7 @@ func TestClientData(t *testing.T) {
 			// Ensure bucketed config has a feature variation map that's empty
 			bucketedUserConfig, err := GenerateBucketedConfig("test", user, nil)
 			require.NoError(t, err)
-			_, _, _, _, _, err = generateBucketedVariableForUser("test", user, "num-var", nil)
+			_, _, _, _, _, err = generateBucketedVariableForUser("test", user, "num-var", nil, time.Now(), true, nil)
 			require.ErrorContainsf(t, err, "does not qualify", "does not qualify")
 			require.Equal(t, map[string]string{}, bucketedUserConfig.FeatureVariationMap)
 
@@ -579,7 +584,7 @@ func TestClientData(t *testing.T) {
 				"614ef6aa473928459060721a": "615357cf7e9ebdca58446ed0",
 				"614ef6aa475928459060721a": "615382338424cb11646d7667",
 			}, bucketedUserConfig.FeatureVariationMap)
-			variableType, value, featureId, variationId, evalReason, err := generateBucketedVariableForUser("test", user, "num-var", clientCustomData)
+			variableType, value, featureId, variationId, evalReason, err := generateBucketedVariableForUser("test", user, "num-var", clientCustomData, time.Now(), true, nil)
 			require.Equal(t, VariableTypesNumber, variableType)
 			require.Equal(t, "614ef6aa473928459060721a", featureId)
 			require.Equal(t, "615357cf7e9ebdca58446ed0", variationId)
@@ -603,7 +608,7 @@ func TestClientData(t *testing.T) {
 				"614ef6aa473928459060721a": "615357cf7e9ebdca58446ed0",
 				"614ef6aa475928459060721a": "615382338424cb11646d7667",
 			}, bucketedUserConfig.FeatureVariationMap)
-			variableType, value, featureId, variationId, evalReason, err = generateBucketedVariableForUser("test", userWithPrivateCustomData, "num-var", clientCustomData)
+			variableType, value, featureId, variationId, evalReason, err = generateBucketedVariableForUser("test", userWithPrivateCustomData, "num-var", clientCustomData, time.Now(), true, nil)
 			require.Equal(t, VariableTypesNumber, variableType)
 			require.Equal(t, "614ef6aa473928459060721a", featureId)
 			require.Equal(t, "615357cf7e9ebdca58446ed0", variationId)
@@ -669,7 +674,7 @@ func TestVariableForUser(t *testing.T) {
 			err := SetConfig(testCase.configBody, "test", "", "", "")
 			require.NoError(t, err)
 
-			variableType, value, featureId, variationId, evalReason, err := generateBucketedVariableForUser("test", user, "json-var", nil)
+			variableType, value, featureId, variationId, evalReason, err := generateBucketedVariableForUser("test", user, "json-var", nil, time.Now(), true, nil)
 			require.NoError(t, err)
 			require.Equal(t, testCase.expectedReason, evalReason)
 			require.Equal(t, VariableTypesJSON, variableType)
@@ -899,3 +904,163 @@ func TestBucketing_Deterministic_BooleanAlternateKeyRandomDistribution(t *testin
 
 	require.Equal(t, bucketedUserConfig3.FeatureVariationMap["614ef8aa475928459060721f"], bucketedUserConfig4.FeatureVariationMap["614ef8aa475928459060721f"])
 }
+
+func TestDetermineUserBucketingValueForTarget_NestedPath(t *testing.T) {
+	data := map[string]interface{}{
+		"account": map[string]interface{}{
+			"id":   "acct-123",
+			"seat": float64(4),
+		},
+		"flat": "value",
+	}
+
+	require.Equal(t, "acct-123", determineUserBucketingValueForTarget(&Target{BucketingKey: "account.id"}, "user", data))
+	require.Equal(t, "4", determineUserBucketingValueForTarget(&Target{BucketingKey: "account.seat"}, "user", data))
+	require.Equal(t, "value", determineUserBucketingValueForTarget(&Target{BucketingKey: "flat"}, "user", data))
+	require.Equal(t, defaultBucketingValue, determineUserBucketingValueForTarget(&Target{BucketingKey: "account.missing"}, "user", data))
+	require.Equal(t, "user", determineUserBucketingValueForTarget(&Target{BucketingKey: "user_id"}, "user", data))
+}
+
+func TestResolveBucketingValue(t *testing.T) {
+	data := map[string]interface{}{
+		"account_id": "acct-123",
+		"org":        map[string]interface{}{"id": "org-1"},
+		"region":     "eu",
+		"empty":      nil,
+	}
+	tests := []struct {
+		name       string
+		target     Target
+		wantValue  string
+		wantSource bucketingKeySource
+	}{
+		{name: "user id", target: Target{}, wantValue: "user", wantSource: bucketingKeySourcePrimary},
+		{name: "custom data key", target: Target{BucketingKey: "account_id", BucketingKeyFallbacks: []string{"user_id"}}, wantValue: "acct-123", wantSource: bucketingKeySourcePrimary},
+		{name: "missing key without fallbacks", target: Target{BucketingKey: "missing"}, wantValue: defaultBucketingValue, wantSource: bucketingKeySourceDefault},
+		{name: "null value falls back", target: Target{BucketingKey: "empty", BucketingKeyFallbacks: []string{"account_id"}}, wantValue: "acct-123", wantSource: bucketingKeySourceFallback},
+		{name: "fallbacks in order", target: Target{BucketingKey: "missing", BucketingKeyFallbacks: []string{"other", "org.id", "user_id"}}, wantValue: "org-1", wantSource: bucketingKeySourceFallback},
+		{name: "fallback to user id", target: Target{BucketingKey: "missing", BucketingKeyFallbacks: []string{"user_id"}}, wantValue: "user", wantSource: bucketingKeySourceFallback},
+		{name: "no fallback has a value", target: Target{BucketingKey: "missing", BucketingKeyFallbacks: []string{"other"}}, wantValue: defaultBucketingValue, wantSource: bucketingKeySourceDefault},
+		{name: "composite", target: Target{BucketingKey: "ignored", BucketingKeys: []string{"org.id", "region"}}, wantValue: "org-1|eu", wantSource: bucketingKeySourcePrimary},
+		{name: "composite with user id", target: Target{BucketingKeys: []string{"account_id", "user_id"}}, wantValue: "acct-123|user", wantSource: bucketingKeySourcePrimary},
+		{name: "incomplete composite falls back", target: Target{BucketingKeys: []string{"org.id", "missing"}, BucketingKeyFallbacks: []string{"account_id"}}, wantValue: "acct-123", wantSource: bucketingKeySourceFallback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.target.compileBucketingKeys()
+			value, source := resolveBucketingValue(&tt.target, "user", data)
+			require.Equal(t, tt.wantValue, value)
+			require.Equal(t, tt.wantSource, source)
+		})
+	}
+}
+
+func TestGetBucketingKeyFallbackCounts(t *testing.T) {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "a", variation: "on"}, {key: "b", variation: "on"}}), &config))
+	for i, feature := range config["features"].([]interface{}) {
+		target := feature.(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+		target["bucketingKey"] = "account_id"
+		if i == 0 {
+			target["bucketingKeyFallbacks"] = []string{"user_id"}
+		}
+	}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	sdkKey := "dvc_server_bucketing_key_fallbacks"
+	require.NoError(t, SetConfig(configJSON, sdkKey, "", "", ""))
+	require.Empty(t, GetBucketingKeyFallbackCounts(sdkKey))
+
+	withAccount := api.User{UserId: "user", CustomData: map[string]interface{}{"account_id": "acct"}}.GetPopulatedUser(&api.PlatformData{})
+	withoutAccount := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+	for _, user := range []api.PopulatedUser{withAccount, withoutAccount, withoutAccount} {
+		_, err := GenerateBucketedConfig(sdkKey, user, nil)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []BucketingKeyFallbackCount{
+		{FeatureKey: "a", TargetId: "target-a", Fallback: 2},
+		{FeatureKey: "b", TargetId: "target-b", Default: 2},
+	}, GetBucketingKeyFallbackCounts(sdkKey))
+}
+
+// rolloutConfig builds a config JSON with a "rollout" feature targeting everyone with the rollout.
+func rolloutConfig(t *testing.T, rollout Rollout) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "rollout", variation: "on"}}), &config))
+	target := config["features"].([]interface{})[0].(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+	target["rollout"] = rollout
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestVariableForUserAt_ScheduledRollout(t *testing.T) {
+	start := time.Date(2024, time.June, 1, 15, 0, 0, 0, time.UTC)
+	sdkKey := "dvc_server_scheduled_rollout"
+	require.NoError(t, SetConfig(rolloutConfig(t, Rollout{Type: "schedule", StartDate: start}), sdkKey, "", "", ""))
+	SetClock(sdkKey, fixedClock(start.Add(-time.Hour)))
+	t.Cleanup(func() { SetClock(sdkKey, nil) })
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	_, _, _, _, _, err := VariableForUser(sdkKey, user, "var-rollout", VariableTypesBool, nil, nil)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets, "the sdk key's clock is before the rollout starts")
+
+	otherSdkKey := sdkKey + "_other"
+	require.NoError(t, SetConfig(rolloutConfig(t, Rollout{Type: "schedule", StartDate: start}), otherSdkKey, "", "", ""))
+	_, _, _, _, _, err = VariableForUser(otherSdkKey, user, "var-rollout", VariableTypesBool, nil, nil)
+	require.NoError(t, err, "clocks are set per sdk key")
+
+	_, _, _, _, _, err = VariableForUserAt(sdkKey, user, "var-rollout", VariableTypesBool, nil, nil, start.Add(-time.Minute))
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+
+	_, value, _, evalReason, _, err := VariableForUserAt(sdkKey, user, "var-rollout", VariableTypesBool, nil, nil, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+	require.Equal(t, api.EvaluationReasonSplit, evalReason)
+
+	bucketedConfig, err := GenerateBucketedConfig(sdkKey, user, nil)
+	require.NoError(t, err)
+	require.NotContains(t, bucketedConfig.Features, "rollout")
+	bucketedConfig, err = GenerateBucketedConfigAt(sdkKey, user, nil, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Contains(t, bucketedConfig.Features, "rollout")
+}
+
+func TestIsUserInRollout_GradualAtTime(t *testing.T) {
+	start := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	rollout := Rollout{
+		Type:      "gradual",
+		StartDate: start,
+		Stages:    []RolloutStage{{Type: "linear", Date: start.Add(100 * time.Hour), Percentage: 1}},
+	}
+
+	require.False(t, isUserInRollout(rollout, 0.25, start.Add(20*time.Hour)))
+	require.True(t, isUserInRollout(rollout, 0.25, start.Add(30*time.Hour)))
+	require.True(t, isUserInRollout(rollout, 0.99, start.Add(100*time.Hour)))
+}
+
+func TestValidatedVariableForUser(t *testing.T) {
+	sdkKey := "dvc_server_validated_variable"
+	store := NewInMemoryAssignmentStore()
+	SetAssignmentStore(sdkKey, store)
+	defer SetAssignmentStore(sdkKey, nil)
+	require.NoError(t, SetConfig(splitConfig(t, 0.5), sdkKey, "", "", ""))
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	_, _, _, evalReason, evalDetails, err := ValidatedVariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, nil, nil, func(value interface{}) error {
+		return errors.New("invalid")
+	})
+	require.ErrorIs(t, err, ErrInvalidJSONValue)
+	require.Equal(t, api.EvaluationReasonDefault, evalReason)
+	require.Equal(t, string(api.DefaultReasonInvalidJSONValue), evalDetails)
+	_, ok, err := store.GetAssignment(user.UserId, "experiment")
+	require.NoError(t, err)
+	require.False(t, ok, "defaulted evaluations don't store assignments")
+
+	_, value, _, _, _, err := ValidatedVariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, nil, nil, func(value interface{}) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, value)
+}
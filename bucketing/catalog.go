@@ -0,0 +1,93 @@
+package bucketing
+
+import (
+	"sort"
+)
+
+// FeatureDescriptor describes a feature in a config.
+type FeatureDescriptor struct {
+	Id       string                 `json:"_id"`
+	Key      string                 `json:"key"`
+	Type     string                 `json:"type"`
+	Settings map[string]interface{} `json:"settings,omitempty"`
+	// VariationKeys are the keys of the feature's variations, in config order
+	VariationKeys []string `json:"variationKeys"`
+	// VariableKeys are the keys of the variables set by the feature's variations, ordered by key
+	VariableKeys []string `json:"variableKeys"`
+}
+
+// VariableDescriptor describes a variable in a config.
+type VariableDescriptor struct {
+	Id   string `json:"_id"`
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// FeatureId and FeatureKey identify the feature that sets the variable, and are empty if no
+	// feature does
+	FeatureId  string `json:"featureId,omitempty"`
+	FeatureKey string `json:"featureKey,omitempty"`
+}
+
+// GetFeatures describes the features of the config set with the given sdk key, ordered by key.
+func GetFeatures(sdkKey string) ([]FeatureDescriptor, error) {
+	config, err := getConfig(sdkKey)
+	if err != nil {
+		return nil, err
+	}
+	featureVariables := make(map[string][]string, len(config.Features))
+	for key, variable := range config.variableKeyMap {
+		if feature := config.GetFeatureForVariableId(variable.Id); feature != nil {
+			featureVariables[feature.Id] = append(featureVariables[feature.Id], key)
+		}
+	}
+
+	features := make([]FeatureDescriptor, 0, len(config.Features))
+	for _, feature := range config.Features {
+		variationKeys := make([]string, len(feature.Variations))
+		for i, variation := range feature.Variations {
+			variationKeys[i] = variation.Key
+		}
+		variableKeys := featureVariables[feature.Id]
+		sort.Strings(variableKeys)
+		var settings map[string]interface{}
+		if feature.Settings != nil {
+			settings = make(map[string]interface{}, len(feature.Settings))
+			for key, value := range feature.Settings {
+				settings[key] = value
+			}
+		}
+		features = append(features, FeatureDescriptor{
+			Id:            feature.Id,
+			Key:           feature.Key,
+			Type:          feature.Type,
+			Settings:      settings,
+			VariationKeys: variationKeys,
+			VariableKeys:  variableKeys,
+		})
+	}
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Key < features[j].Key
+	})
+	return features, nil
+}
+
+// GetVariablesCatalog describes the variables of the config set with the given sdk key, ordered
+// by key.
+func GetVariablesCatalog(sdkKey string) ([]VariableDescriptor, error) {
+	config, err := getConfig(sdkKey)
+	if err != nil {
+		return nil, err
+	}
+	variables := make([]VariableDescriptor, 0, len(config.variableKeyMap))
+	for _, variable := range config.variableKeyMap {
+		descriptor := VariableDescriptor{Id: variable.Id, Key: variable.Key, Type: variable.Type}
+		if feature := config.GetFeatureForVariableId(variable.Id); feature != nil {
+			descriptor.FeatureId = feature.Id
+			descriptor.FeatureKey = feature.Key
+		}
+		variables = append(variables, descriptor)
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Key < variables[j].Key
+	})
+	return variables, nil
+}
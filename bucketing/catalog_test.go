@@ -0,0 +1,37 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFeaturesAndVariablesCatalog(t *testing.T) {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "b", variation: "on"}, {key: "a", variation: "on"}}), &config))
+	config["features"].([]interface{})[0].(map[string]interface{})["settings"] = map[string]interface{}{"publicName": "B"}
+	config["variables"] = append(config["variables"].([]interface{}), map[string]interface{}{"_id": "unused", "key": "unused", "type": "String"})
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	sdkKey := "dvc_server_catalog"
+	require.NoError(t, SetConfig(configJSON, sdkKey, "", "", ""))
+
+	features, err := GetFeatures(sdkKey)
+	require.NoError(t, err)
+	require.Equal(t, []FeatureDescriptor{
+		{Id: "a", Key: "a", Type: "release", VariationKeys: []string{"on", "off"}, VariableKeys: []string{"var-a"}},
+		{Id: "b", Key: "b", Type: "release", Settings: map[string]interface{}{"publicName": "B"}, VariationKeys: []string{"on", "off"}, VariableKeys: []string{"var-b"}},
+	}, features)
+
+	variables, err := GetVariablesCatalog(sdkKey)
+	require.NoError(t, err)
+	require.Equal(t, []VariableDescriptor{
+		{Id: "unused", Key: "unused", Type: "String"},
+		{Id: "var-a", Key: "var-a", Type: "Boolean", FeatureId: "a", FeatureKey: "a"},
+		{Id: "var-b", Key: "var-b", Type: "Boolean", FeatureId: "b", FeatureKey: "b"},
+	}, variables)
+
+	_, err = GetFeatures("dvc_server_catalog_missing")
+	require.Error(t, err)
+}
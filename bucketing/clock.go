@@ -0,0 +1,39 @@
+package bucketing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// Clock provides the current time for time-dependent evaluation, such as rollout schedules and
+// relative date filters.
+type Clock = api.Clock
+
+var clocks = make(map[string]Clock)
+var clockMutex = &sync.RWMutex{}
+
+// SetClock sets the clock used to evaluate configs set with the given sdk key. Passing nil
+// restores the system clock. Evaluations at an explicit time, such as VariableForUserAt, don't
+// use the clock.
+func SetClock(sdkKey string, clock Clock) {
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+	if clock == nil {
+		delete(clocks, sdkKey)
+		return
+	}
+	clocks[sdkKey] = clock
+}
+
+// now returns the current time for evaluating configs set with the given sdk key.
+func now(sdkKey string) time.Time {
+	clockMutex.RLock()
+	clock, ok := clocks[sdkKey]
+	clockMutex.RUnlock()
+	if !ok {
+		return time.Now()
+	}
+	return clock.Now()
+}
@@ -0,0 +1,353 @@
+package bucketing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// evaluationContext holds the user data that is prepared once per bucketing pass and shared by
+// every feature and target evaluated for that user.
+type evaluationContext struct {
+	user             api.PopulatedUser
+	clientCustomData map[string]interface{}
+	mergedCustomData map[string]interface{}
+	// now is the time the evaluation happens at, used for rollout schedules and date filters
+	now time.Time
+	// audienceResults memoizes audience evaluations by the slot assigned when the config was
+	// compiled, so a context must only be used with a single config.
+	audienceResults []audienceResult
+	// prerequisiteVariations memoizes the variation served for each prerequisite feature id
+	prerequisiteVariations map[string]*Variation
+	// layerHashes holds the user's hash for each layer id
+	layerHashes map[string]float64
+	// assignmentStore is consulted for sticky assignments to split targets, if set
+	assignmentStore AssignmentStore
+	// storeAssignments is set when the evaluation serves a variable, which stores new assignments
+	storeAssignments bool
+	// targetTraces records how each feature's targets were evaluated, by feature id, if set
+	targetTraces map[string][]TargetTrace
+}
+
+type audienceResult uint8
+
+const (
+	audienceNotEvaluated audienceResult = iota
+	audienceMatched
+	audienceNotMatched
+)
+
+func newEvaluationContext(user api.PopulatedUser, clientCustomData map[string]interface{}, at time.Time) *evaluationContext {
+	user, clientCustomData = normalizeUserCustomData(user, clientCustomData)
+	return &evaluationContext{
+		user:             user,
+		clientCustomData: clientCustomData,
+		mergedCustomData: user.CombinedCustomData(),
+		now:              at,
+	}
+}
+
+// evaluateAudience returns the memoized result of the audience in slot, evaluating it on first use.
+func (ctx *evaluationContext) evaluateAudience(slot int, evaluate func(ctx *evaluationContext) bool) bool {
+	if slot < len(ctx.audienceResults) {
+		switch ctx.audienceResults[slot] {
+		case audienceMatched:
+			return true
+		case audienceNotMatched:
+			return false
+		}
+	} else {
+		ctx.audienceResults = append(ctx.audienceResults, make([]audienceResult, slot+1-len(ctx.audienceResults))...)
+	}
+
+	result := evaluate(ctx)
+	if result {
+		ctx.audienceResults[slot] = audienceMatched
+	} else {
+		ctx.audienceResults[slot] = audienceNotMatched
+	}
+	return result
+}
+
+// Relative evaluation costs, used to order the operands of and/or operators so that cheap
+// filters short-circuit before expensive ones.
+const (
+	filterCostConstant   = 0
+	filterCostUser       = 1
+	filterCostCustomData = 2
+	filterCostVersion    = 4
+	filterCostExternal   = 8
+	// Prerequisites bucket the user into another feature
+	filterCostPrerequisite = 16
+)
+
+// A compiledFilter is a filter tree flattened into a closure when the config is loaded.
+type compiledFilter struct {
+	evaluate func(ctx *evaluationContext) bool
+	cost     int
+	// isConstant is set when the result doesn't depend on the user, e.g. an "all" filter
+	isConstant bool
+}
+
+func constantFilter(result bool) compiledFilter {
+	return compiledFilter{
+		evaluate:   func(*evaluationContext) bool { return result },
+		cost:       filterCostConstant,
+		isConstant: true,
+	}
+}
+
+var (
+	compiledPass = constantFilter(true)
+	compiledFail = constantFilter(false)
+)
+
+// filterCompiler compiles target audiences against the audiences of a single config. Each
+// referenced audience is compiled once and shared by every audienceMatch filter that uses it,
+// and its result is memoized per evaluation in the slot it was assigned.
+type filterCompiler struct {
+	// config is the config prerequisite features are bucketed in, prerequisites never pass without one
+	config    *configBody
+	audiences map[string]NoIdAudience
+	compiled  map[string]*compiledFilter
+	visiting  map[string]bool
+	slots     int
+}
+
+func newFilterCompiler(audiences map[string]NoIdAudience) *filterCompiler {
+	return &filterCompiler{
+		audiences: audiences,
+		compiled:  make(map[string]*compiledFilter, len(audiences)),
+		visiting:  make(map[string]bool),
+	}
+}
+
+func (c *filterCompiler) compile(f FilterOrOperator) compiledFilter {
+	switch filter := f.(type) {
+	case nil:
+		return compiledFail
+	case *AudienceOperator:
+		if filter == nil {
+			return compiledFail
+		}
+		return c.compileOperator(filter.Operator, filter.Filters)
+	case AudienceOperator:
+		return c.compileOperator(filter.Operator, filter.Filters)
+	case AllFilter, *AllFilter:
+		return compiledPass
+	case OptInFilter, *OptInFilter:
+		return compiledFail
+	case *UnknownFilter:
+		return constantFilter(filter.pass)
+	case *CustomDataFilter:
+		return compiledFilter{
+			evaluate: func(ctx *evaluationContext) bool {
+				return checkCustomData(filter, ctx.mergedCustomData, ctx.clientCustomData, ctx.now)
+			},
+			cost: filterCostCustomData,
+		}
+	case *UserFilter:
+		cost := filterCostUser
+		if filter.SubType == SubTypeAppVersion || filter.SubType == SubTypePlatformVersion {
+			cost = filterCostVersion
+		}
+		return compiledFilter{
+			evaluate: func(ctx *evaluationContext) bool {
+				return filterFunctionsBySubtype(filter, ctx.user, ctx.clientCustomData)
+			},
+			cost: cost,
+		}
+	case *AudienceMatchFilter:
+		return c.compileAudienceMatch(filter)
+	case *PrerequisiteFilter:
+		config := c.config
+		if config == nil {
+			return compiledFail
+		}
+		return compiledFilter{
+			evaluate: func(ctx *evaluationContext) bool {
+				return prerequisiteMet(config, filter, ctx)
+			},
+			cost: filterCostPrerequisite,
+		}
+	default:
+		audiences := c.audiences
+		return compiledFilter{
+			evaluate: func(ctx *evaluationContext) bool {
+				return f.Evaluate(audiences, ctx.user, ctx.clientCustomData)
+			},
+			cost: filterCostExternal,
+		}
+	}
+}
+
+func (c *filterCompiler) compileOperator(operator string, filters []FilterOrOperator) compiledFilter {
+	if len(filters) == 0 || (operator != OperatorAnd && operator != OperatorOr) {
+		return compiledFail
+	}
+
+	return combineOperands(operator, c.flattenOperands(operator, filters, nil))
+}
+
+// combineOperands folds constant operands and orders the remaining ones by cost.
+func combineOperands(operator string, compiledOperands []compiledFilter) compiledFilter {
+	// For "and" a failing operand decides the result and passing operands can be dropped,
+	// for "or" it's the other way around.
+	decidingResult := operator == OperatorOr
+	var operands []compiledFilter
+	for _, operand := range compiledOperands {
+		if operand.isConstant {
+			if operand.evaluate(nil) == decidingResult {
+				return constantFilter(decidingResult)
+			}
+			continue
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 0 {
+		return constantFilter(!decidingResult)
+	}
+	if len(operands) == 1 {
+		return operands[0]
+	}
+
+	sort.SliceStable(operands, func(i, j int) bool {
+		return operands[i].cost < operands[j].cost
+	})
+	evaluators := make([]func(ctx *evaluationContext) bool, len(operands))
+	cost := 0
+	for i, operand := range operands {
+		evaluators[i] = operand.evaluate
+		cost += operand.cost
+	}
+
+	if operator == OperatorOr {
+		return compiledFilter{
+			evaluate: func(ctx *evaluationContext) bool {
+				for _, evaluate := range evaluators {
+					if evaluate(ctx) {
+						return true
+					}
+				}
+				return false
+			},
+			cost: cost,
+		}
+	}
+	return compiledFilter{
+		evaluate: func(ctx *evaluationContext) bool {
+			for _, evaluate := range evaluators {
+				if !evaluate(ctx) {
+					return false
+				}
+			}
+			return true
+		},
+		cost: cost,
+	}
+}
+
+// flattenOperands inlines nested operators that use the same logical operator as their parent.
+// Empty operators are kept as they are, since they never pass.
+func (c *filterCompiler) flattenOperands(operator string, filters []FilterOrOperator, operands []compiledFilter) []compiledFilter {
+	for _, f := range filters {
+		var nested *AudienceOperator
+		switch filter := f.(type) {
+		case *AudienceOperator:
+			nested = filter
+		case AudienceOperator:
+			nested = &filter
+		}
+		if nested != nil && nested.Operator == operator && len(nested.Filters) > 0 {
+			operands = c.flattenOperands(operator, nested.Filters, operands)
+			continue
+		}
+		operands = append(operands, c.compile(f))
+	}
+	return operands
+}
+
+func (c *filterCompiler) compileAudienceMatch(filter *AudienceMatchFilter) compiledFilter {
+	comparator := filter.GetComparator()
+	if comparator != ComparatorEqual && comparator != ComparatorNotEqual {
+		return compiledFail
+	}
+
+	matchResult := comparator == ComparatorEqual
+	// A missing audience is kept as a nil evaluator, as it fails the filter when it is reached.
+	// Leading constant audiences are folded the same way as constant operands.
+	var evaluators []func(ctx *evaluationContext) bool
+	cost := filterCostUser
+	for _, audienceId := range filter.Audiences {
+		audience := c.compileAudience(audienceId)
+		if len(evaluators) == 0 {
+			if audience == nil {
+				return compiledFail
+			}
+			if audience.isConstant {
+				if audience.evaluate(nil) {
+					return constantFilter(matchResult)
+				}
+				continue
+			}
+		}
+		if audience == nil {
+			evaluators = append(evaluators, nil)
+			continue
+		}
+		evaluators = append(evaluators, audience.evaluate)
+		cost += audience.cost
+	}
+	if len(evaluators) == 0 {
+		return constantFilter(!matchResult)
+	}
+
+	return compiledFilter{
+		evaluate: func(ctx *evaluationContext) bool {
+			for _, evaluate := range evaluators {
+				if evaluate == nil {
+					return false
+				}
+				if evaluate(ctx) {
+					return matchResult
+				}
+			}
+			return !matchResult
+		},
+		cost: cost,
+	}
+}
+
+// compileAudience returns the compiled filters of a config audience, or nil if it doesn't exist.
+// A reference back to an audience that is still being compiled never matches.
+func (c *filterCompiler) compileAudience(audienceId string) *compiledFilter {
+	if compiled, ok := c.compiled[audienceId]; ok {
+		return compiled
+	}
+	audience, ok := c.audiences[audienceId]
+	if !ok {
+		return nil
+	}
+	if c.visiting[audienceId] {
+		return &compiledFail
+	}
+
+	c.visiting[audienceId] = true
+	compiled := compiledFail
+	if audience.Filters != nil {
+		compiled = c.compile(audience.Filters)
+	}
+	delete(c.visiting, audienceId)
+
+	if !compiled.isConstant {
+		slot := c.slots
+		c.slots++
+		evaluate := compiled.evaluate
+		compiled.evaluate = func(ctx *evaluationContext) bool {
+			return ctx.evaluateAudience(slot, evaluate)
+		}
+	}
+	c.compiled[audienceId] = &compiled
+	return &compiled
+}
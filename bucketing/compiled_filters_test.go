@@ -0,0 +1,288 @@
+package bucketing
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+func largeConfigUsers() []api.PopulatedUser {
+	customDataValues := []map[string]interface{}{
+		nil,
+		{"data-key-6": "iYI6uwZed0ip"},
+		{"data-key-8": "t2v2OAaQxGTl", "data-key-6": "h6fCse1VCIo1"},
+		{"data-key-7": "3yejExtXkma4", "data-key-10": "PyPREARJvoiq"},
+		{"data-key-1": "xxJH0EYYW1xW0ixx", "data-key-5": "krnFTNsIxvJA"},
+	}
+	var users []api.PopulatedUser
+	for i := 0; i < 50; i++ {
+		users = append(users, api.PopulatedUser{
+			User: api.User{
+				UserId:     fmt.Sprintf("user_%d", i),
+				Email:      fmt.Sprintf("user%d@example.com", i),
+				CustomData: customDataValues[i%len(customDataValues)],
+			},
+			PlatformData: &api.PlatformData{Platform: "Go", PlatformVersion: "1.22.0"},
+		})
+	}
+	users = append(users, api.PopulatedUser{
+		User:         api.User{UserId: "user_680f420d-a65f-406c-8aaf-0b39a617e696"},
+		PlatformData: &api.PlatformData{},
+	})
+	return users
+}
+
+// withoutCompiledTargets returns a copy of the config that evaluates targets by walking the
+// filter tree, the way configs were evaluated before compilation.
+func withoutCompiledTargets(config *configBody) *configBody {
+	interpreted := *config
+	interpreted.compiledTargets = nil
+	return &interpreted
+}
+
+func TestCompiledFilters_MatchInterpretedEvaluation(t *testing.T) {
+	largeConfig, err := os.ReadFile("../testdata/fixture_large_config.json")
+	require.NoError(t, err)
+
+	for name, rawConfig := range map[string][]byte{
+		"test config":    test_config,
+		"test v2 config": test_v2_config,
+		"large config":   largeConfig,
+	} {
+		t.Run(name, func(t *testing.T) {
+			config, err := newConfig(rawConfig, "", "", "")
+			require.NoError(t, err)
+			interpreted := withoutCompiledTargets(config)
+
+			for _, user := range largeConfigUsers() {
+				ctx := newEvaluationContext(user, map[string]interface{}{"favouriteFood": "pizza"}, time.Now())
+				for _, feature := range config.Features {
+					for _, target := range feature.Configuration.Targets {
+						require.Equal(t,
+							interpreted.targetMatchesAudience(target, ctx),
+							config.targetMatchesAudience(target, ctx),
+							"feature %s target %s user %s", feature.Key, target.Id, user.UserId,
+						)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFilterCompiler_Operators(t *testing.T) {
+	emailFilter := &UserFilter{
+		filter: filter{Type: TypeUser, SubType: SubTypeEmail, Comparator: ComparatorEqual},
+		Values: []interface{}{"brooks@big.lunch"},
+	}
+	require.NoError(t, emailFilter.Initialize())
+	versionFilter := &UserFilter{
+		filter: filter{Type: TypeUser, SubType: SubTypeAppVersion, Comparator: ComparatorGreater},
+		Values: []interface{}{"1.0.0"},
+	}
+	require.NoError(t, versionFilter.Initialize())
+	ctx := newEvaluationContext(brooks, nil, time.Now())
+
+	testCases := []struct {
+		name       string
+		operator   *AudienceOperator
+		expected   bool
+		isConstant bool
+	}{
+		{
+			name:       "empty operator never passes",
+			operator:   &AudienceOperator{Operator: OperatorAnd},
+			expected:   false,
+			isConstant: true,
+		},
+		{
+			name:       "unknown operator never passes",
+			operator:   &AudienceOperator{Operator: "xylophone", Filters: MixedFilters{&AllFilter{}}},
+			expected:   false,
+			isConstant: true,
+		},
+		{
+			name:       "and of all filters is constant",
+			operator:   &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{&AllFilter{}, AllFilter{}}},
+			expected:   true,
+			isConstant: true,
+		},
+		{
+			name:       "and with opt-in is constant",
+			operator:   &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{versionFilter, &OptInFilter{}}},
+			expected:   false,
+			isConstant: true,
+		},
+		{
+			name:       "or with all is constant",
+			operator:   &AudienceOperator{Operator: OperatorOr, Filters: MixedFilters{versionFilter, &AllFilter{}}},
+			expected:   true,
+			isConstant: true,
+		},
+		{
+			name: "nested and is flattened",
+			operator: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{
+				&AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{versionFilter, &AllFilter{}}},
+				emailFilter,
+			}},
+			expected: true,
+		},
+		{
+			name: "nested empty operator still fails",
+			operator: &AudienceOperator{Operator: OperatorOr, Filters: MixedFilters{
+				&AudienceOperator{Operator: OperatorOr},
+			}},
+			expected:   false,
+			isConstant: true,
+		},
+		{
+			name:     "nil filter never passes",
+			operator: &AudienceOperator{Operator: OperatorOr, Filters: MixedFilters{nil, emailFilter}},
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compiled := newFilterCompiler(nil).compile(tc.operator)
+			require.Equal(t, tc.expected, compiled.evaluate(ctx))
+			require.Equal(t, tc.isConstant, compiled.isConstant)
+		})
+	}
+}
+
+func TestFilterCompiler_OrdersOperandsByCost(t *testing.T) {
+	var evaluated []string
+	tracked := func(name string, cost int) compiledFilter {
+		return compiledFilter{
+			evaluate: func(*evaluationContext) bool {
+				evaluated = append(evaluated, name)
+				return false
+			},
+			cost: cost,
+		}
+	}
+	operands := []compiledFilter{tracked("expensive", filterCostVersion), tracked("cheap", filterCostUser)}
+
+	compiled := combineOperands(OperatorAnd, operands)
+	require.False(t, compiled.evaluate(newEvaluationContext(brooks, nil, time.Now())))
+	require.Equal(t, []string{"cheap"}, evaluated, "and should short-circuit on the cheaper operand")
+	require.Equal(t, filterCostVersion+filterCostUser, compiled.cost)
+}
+
+func TestFilterCompiler_AudienceMatch(t *testing.T) {
+	countryFilter := &UserFilter{
+		filter: filter{Type: TypeUser, SubType: SubTypeCountry, Comparator: ComparatorEqual},
+		Values: []interface{}{"Canada"},
+	}
+	require.NoError(t, countryFilter.Initialize())
+	audiences := map[string]NoIdAudience{
+		"canada":    {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{countryFilter}}},
+		"nested":    {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{&AudienceMatchFilter{filter: filter{Type: TypeAudienceMatch, Comparator: "="}, Audiences: []string{"canada"}}}}},
+		"recursive": {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{&AudienceMatchFilter{filter: filter{Type: TypeAudienceMatch, Comparator: "="}, Audiences: []string{"recursive"}}}}},
+		"everyone":  {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{&AllFilter{}}}},
+	}
+
+	testCases := []struct {
+		name       string
+		comparator string
+		audiences  []string
+		expected   bool
+		isConstant bool
+	}{
+		{name: "matches", comparator: "=", audiences: []string{"canada"}, expected: true},
+		{name: "nested", comparator: "=", audiences: []string{"nested"}, expected: true},
+		{name: "not in audience", comparator: "!=", audiences: []string{"canada"}, expected: false},
+		{name: "missing audience", comparator: "!=", audiences: []string{"missing"}, expected: false, isConstant: true},
+		{name: "match before missing audience", comparator: "=", audiences: []string{"canada", "missing"}, expected: true},
+		{name: "recursive audience never matches", comparator: "=", audiences: []string{"recursive"}, expected: false, isConstant: true},
+		{name: "audience matching every user", comparator: "=", audiences: []string{"recursive", "everyone"}, expected: true, isConstant: true},
+		{name: "not in audience matching every user", comparator: "!=", audiences: []string{"everyone", "canada"}, expected: false, isConstant: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchFilter := &AudienceMatchFilter{
+				filter:    filter{Type: TypeAudienceMatch, Comparator: tc.comparator},
+				Audiences: tc.audiences,
+			}
+			compiled := newFilterCompiler(audiences).compile(&AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{matchFilter}})
+			require.Equal(t, tc.expected, compiled.evaluate(newEvaluationContext(brooks, nil, time.Now())))
+			require.Equal(t, tc.isConstant, compiled.isConstant)
+		})
+	}
+}
+
+func benchmarkLargeConfigSegmentation(b *testing.B, compiled bool) {
+	rawConfig, err := os.ReadFile("../testdata/fixture_large_config.json")
+	require.NoError(b, err)
+	config, err := newConfig(rawConfig, "", "", "")
+	require.NoError(b, err)
+	if !compiled {
+		config = withoutCompiledTargets(config)
+	}
+	users := largeConfigUsers()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx := newEvaluationContext(users[i%len(users)], nil, time.Now())
+		for _, feature := range config.Features {
+			_, _, _ = doesUserQualifyForFeature(config, feature, ctx)
+		}
+	}
+}
+
+func BenchmarkSegmentation_LargeConfig_Compiled(b *testing.B) {
+	benchmarkLargeConfigSegmentation(b, true)
+}
+
+func BenchmarkSegmentation_LargeConfig_Interpreted(b *testing.B) {
+	benchmarkLargeConfigSegmentation(b, false)
+}
+
+// countingFilter is a filter that records how many times it was evaluated.
+type countingFilter struct {
+	evaluations *int
+	result      bool
+}
+
+func (f countingFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
+	*f.evaluations++
+	return f.result
+}
+
+func TestFilterCompiler_MemoizesAudiencesPerEvaluation(t *testing.T) {
+	config, err := newConfig(test_config, "", "", "")
+	require.NoError(t, err)
+
+	evaluations := 0
+	config.Audiences = map[string]NoIdAudience{
+		"shared": {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{countingFilter{evaluations: &evaluations}}}},
+	}
+	matchShared := &AudienceMatchFilter{filter: filter{Type: TypeAudienceMatch, Comparator: "="}, Audiences: []string{"shared"}}
+	targets := 0
+	for _, feature := range config.Features {
+		for _, target := range feature.Configuration.Targets {
+			target.Audience.Filters = &AudienceOperator{Operator: OperatorOr, Filters: MixedFilters{matchShared, matchShared}}
+			targets++
+		}
+	}
+	require.Greater(t, targets, 1)
+	config.compile("", "", "")
+
+	ctx := newEvaluationContext(brooks, nil, time.Now())
+	for _, feature := range config.Features {
+		_, _, err := doesUserQualifyForFeature(config, feature, ctx)
+		require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+	}
+	require.Equal(t, 1, evaluations, "shared audience should be evaluated once per evaluation")
+
+	_, _, err = doesUserQualifyForFeature(config, config.Features[0], newEvaluationContext(brooks, nil, time.Now()))
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+	require.Equal(t, 2, evaluations, "a new evaluation should not reuse memoized results")
+}
@@ -9,6 +9,33 @@ var internalConfigs = make(map[string]*configBody)
 var internalRawConfigs = make(map[string][]byte)
 var configMutex = &sync.RWMutex{}
 
+// configOptions control how the configs set with an sdk key are loaded.
+type configOptions struct {
+	unknownFilterPolicy       UnknownFilterPolicy
+	quarantineInvalidFeatures bool
+}
+
+var sdkConfigOptions = make(map[string]configOptions)
+var configOptionsMutex = &sync.RWMutex{}
+
+func updateConfigOptions(sdkKey string, update func(options *configOptions)) {
+	configOptionsMutex.Lock()
+	defer configOptionsMutex.Unlock()
+	options := sdkConfigOptions[sdkKey]
+	update(&options)
+	if options == (configOptions{}) {
+		delete(sdkConfigOptions, sdkKey)
+		return
+	}
+	sdkConfigOptions[sdkKey] = options
+}
+
+func getConfigOptions(sdkKey string) configOptions {
+	configOptionsMutex.RLock()
+	defer configOptionsMutex.RUnlock()
+	return sdkConfigOptions[sdkKey]
+}
+
 func getConfig(sdkKey string) (*configBody, error) {
 	configMutex.RLock()
 	defer configMutex.RUnlock()
@@ -52,7 +79,7 @@ func GetRawConfig(sdkKey string) []byte {
 }
 
 func SetConfig(rawJSON []byte, sdkKey, etag, rayId, lastModified string) error {
-	config, err := newConfig(rawJSON, etag, rayId, lastModified)
+	config, err := newConfigWithOptions(rawJSON, etag, rayId, lastModified, getConfigOptions(sdkKey))
 	if err != nil {
 		return err
 	}
@@ -14,6 +14,7 @@ const (
 	TypeUser          = "user"
 	TypeOptIn         = "optIn"
 	TypeAudienceMatch = "audienceMatch"
+	TypePrerequisite  = "prerequisite"
 )
 
 const (
@@ -26,6 +27,8 @@ const (
 	SubTypeAppVersion      = "appVersion"
 	SubTypeDeviceModel     = "deviceModel"
 	SubTypeCustomData      = "customData"
+	SubTypeLanguage        = "language"
+	SubTypeName            = "name"
 )
 
 const (
@@ -43,12 +46,21 @@ const (
 	ComparatorNotStartWith = "!startWith"
 	ComparatorEndWith      = "endWith"
 	ComparatorNotEndWith   = "!endWith"
+	ComparatorBefore       = "before"
+	ComparatorAfter        = "after"
+	ComparatorBetween      = "between"
+	ComparatorWithinLast   = "withinLastDays"
+	ComparatorWithinNext   = "withinNextDays"
+	ComparatorContainsAll  = "containsAll"
+	ComparatorContainsAny  = "containsAny"
+	ComparatorSize         = "size"
 )
 
 const (
 	DataKeyTypeString  = "String"
 	DataKeyTypeBoolean = "Boolean"
 	DataKeyTypeNumber  = "Number"
+	DataKeyTypeDate    = "Date"
 )
 
 const (
@@ -0,0 +1,176 @@
+package bucketing
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// normalizeUserCustomData returns a copy of the user and client custom data with list values
+// converted to []interface{} of float64, string and bool elements, so that filters don't need
+// to handle every slice type. The original maps are only copied if they contain a list.
+func normalizeUserCustomData(user api.PopulatedUser, clientCustomData map[string]interface{}) (api.PopulatedUser, map[string]interface{}) {
+	user.CustomData = normalizeCustomDataMap(user.CustomData)
+	user.PrivateCustomData = normalizeCustomDataMap(user.PrivateCustomData)
+	return user, normalizeCustomDataMap(clientCustomData)
+}
+
+func normalizeCustomDataMap(data map[string]interface{}) map[string]interface{} {
+	var normalized map[string]interface{}
+	for key, value := range data {
+		list, ok := normalizeCustomDataList(value)
+		if !ok {
+			continue
+		}
+		if normalized == nil {
+			normalized = make(map[string]interface{}, len(data))
+			for k, v := range data {
+				normalized[k] = v
+			}
+		}
+		normalized[key] = list
+	}
+	if normalized == nil {
+		return data
+	}
+	return normalized
+}
+
+// normalizeCustomDataList converts any slice or array value into a []interface{} with numeric
+// elements widened to float64. It returns false for values that are not lists.
+func normalizeCustomDataList(value interface{}) ([]interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if _, isBytes := value.([]byte); isBytes {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		list[i] = normalizeCustomDataScalar(rv.Index(i).Interface())
+	}
+	return list, true
+}
+
+func normalizeCustomDataScalar(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return value
+	}
+}
+
+// parseDataKeyPath splits a dot-separated custom data key into path segments. A literal dot or
+// backslash inside a segment is escaped with a backslash, e.g. `domains.example\.com`.
+func parseDataKeyPath(key string) []string {
+	if !strings.ContainsAny(key, `.\`) {
+		return []string{key}
+	}
+	var path []string
+	var segment strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '\\':
+			if i+1 < len(key) {
+				i++
+			}
+			segment.WriteByte(key[i])
+		case '.':
+			path = append(path, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(key[i])
+		}
+	}
+	return append(path, segment.String())
+}
+
+// lookupCustomDataPath resolves a custom data key in data. An exact top-level match takes
+// precedence so existing keys containing dots keep working, otherwise the compiled path is
+// followed through nested maps and lists, with list elements addressed by index.
+func lookupCustomDataPath(data map[string]interface{}, key string, path []string) (interface{}, bool) {
+	if data == nil {
+		return nil, false
+	}
+	if value, ok := data[key]; ok {
+		return value, true
+	}
+	if len(path) == 0 || (len(path) == 1 && path[0] == key) {
+		return nil, false
+	}
+
+	current, ok := data[path[0]]
+	if !ok {
+		return nil, false
+	}
+	for _, segment := range path[1:] {
+		if current, ok = lookupPathSegment(current, segment); !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func lookupPathSegment(value interface{}, segment string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[segment]
+		return child, ok
+	case []interface{}:
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= len(v) {
+			return nil, false
+		}
+		return v[index], true
+	case nil:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		child := rv.MapIndex(reflect.ValueOf(segment).Convert(rv.Type().Key()))
+		if !child.IsValid() {
+			return nil, false
+		}
+		return child.Interface(), true
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= rv.Len() {
+			return nil, false
+		}
+		return rv.Index(index).Interface(), true
+	default:
+		return nil, false
+	}
+}
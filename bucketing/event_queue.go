@@ -18,11 +18,12 @@ import (
 var ErrQueueFull = fmt.Errorf("max queue size reached")
 
 type aggEventData struct {
-	eventType   string
-	variableKey string
-	featureId   string
-	variationId string
-	eval        eventEval
+	eventType     string
+	variableKey   string
+	featureId     string
+	variationId   string
+	defaultReason api.DefaultReason
+	eval          eventEval
 }
 
 type eventEval map[api.EvaluationReason]int64 // map eval reason -> count
@@ -41,6 +42,8 @@ type userEventData struct {
 // ["aggVariableEvaluated"]["somevariablekey"]["feature_id"]["variation_id"]["eval reason"] = 1
 // For Defaulted Events:
 // ["aggVariableDefaulted"]["somevariablekey"]["DEFAULT"]["DEFAULT"]["DEFAULT_REASON"] = 1
+// or, for defaults caused by an unmet prerequisite:
+// ["aggVariableDefaulted"]["somevariablekey"]["DEFAULT"]["Prerequisite Not Met"]["DEFAULT_REASON"] = 1
 
 type EvalReasonAggMap map[api.EvaluationReason]int64
 type VariationAggMap map[string]EvalReasonAggMap
@@ -57,7 +60,7 @@ func (u *UserEventQueue) BuildBatchRecords() []api.UserEventsBatchRecord {
 	return records
 }
 
-func (agg *AggregateEventQueue) BuildBatchRecords(platformData *api.PlatformData, clientUUID, configEtag, rayId, lastModified string) api.UserEventsBatchRecord {
+func (agg *AggregateEventQueue) BuildBatchRecords(platformData *api.PlatformData, clientUUID, configEtag, rayId, lastModified string, clientDate time.Time) api.UserEventsBatchRecord {
 	var aggregateEvents []api.Event
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -78,7 +81,7 @@ func (agg *AggregateEventQueue) BuildBatchRecords(platformData *api.PlatformData
 						Target:      variableKey,
 						UserId:      userId,
 						FeatureVars: emptyFeatureVars,
-						ClientDate:  time.Now(),
+						ClientDate:  clientDate,
 					}
 					metaData := make(map[string]interface{})
 					evalMetadata := make(map[string]int64)
@@ -86,6 +89,9 @@ func (agg *AggregateEventQueue) BuildBatchRecords(platformData *api.PlatformData
 						evalMetadata[string(api.EvaluationReasonDefault)]++
 						metaData["eval"] = evalMetadata
 						metaData["_variation"] = api.EvaluationReasonDefault
+						if variation == string(api.DefaultReasonPrerequisiteNotMet) {
+							metaData["defaultReason"] = variation
+						}
 					} else {
 						metaData = map[string]interface{}{
 							"_variation": variation,
@@ -178,7 +184,7 @@ func NewEventQueue(sdkKey string, options *api.EventQueueOptions, platformData *
 	return eq, nil
 }
 
-func (eq *EventQueue) queueAggregateEventInternal(variableKey, featureId, variationId, eventType string, evalReason api.EvaluationReason) error {
+func (eq *EventQueue) queueAggregateEventInternal(variableKey, featureId, variationId, eventType string, evalReason api.EvaluationReason, defaultReason api.DefaultReason) error {
 	if eq.options != nil && eq.options.IsEventLoggingDisabled(eventType) {
 		return nil
 	}
@@ -196,11 +202,12 @@ func (eq *EventQueue) queueAggregateEventInternal(variableKey, featureId, variat
 
 	select {
 	case eq.aggEventQueueRaw <- aggEventData{
-		eventType:   eventType,
-		variableKey: variableKey,
-		featureId:   featureId,
-		variationId: variationId,
-		eval:        eval,
+		eventType:     eventType,
+		variableKey:   variableKey,
+		featureId:     featureId,
+		variationId:   variationId,
+		defaultReason: defaultReason,
+		eval:          eval,
 	}:
 	default:
 		eq.eventsDropped.Add(1)
@@ -230,7 +237,7 @@ func (eq *EventQueue) QueueVariableEvaluatedEvent(variableKey, featureId, variat
 		return nil
 	}
 
-	return eq.queueAggregateEventInternal(variableKey, featureId, variationId, api.EventType_AggVariableEvaluated, evalReason)
+	return eq.queueAggregateEventInternal(variableKey, featureId, variationId, api.EventType_AggVariableEvaluated, evalReason, api.DefaultReasonNotDefaulted)
 }
 
 func (eq *EventQueue) QueueVariableDefaultedEvent(variableKey string, defaultReason api.DefaultReason) error {
@@ -238,7 +245,7 @@ func (eq *EventQueue) QueueVariableDefaultedEvent(variableKey string, defaultRea
 		return nil
 	}
 
-	return eq.queueAggregateEventInternal(variableKey, "", "", api.EventType_AggVariableDefaulted, api.EvaluationReasonDefault)
+	return eq.queueAggregateEventInternal(variableKey, "", "", api.EventType_AggVariableDefaulted, api.EvaluationReasonDefault, defaultReason)
 }
 
 func (eq *EventQueue) FlushEventQueue(clientUUID, configEtag, rayId, lastModified string) (map[string]api.FlushPayload, error) {
@@ -247,7 +254,7 @@ func (eq *EventQueue) FlushEventQueue(clientUUID, configEtag, rayId, lastModifie
 
 	var records []api.UserEventsBatchRecord
 
-	records = append(records, eq.aggEventQueue.BuildBatchRecords(eq.platformData, clientUUID, configEtag, rayId, lastModified))
+	records = append(records, eq.aggEventQueue.BuildBatchRecords(eq.platformData, clientUUID, configEtag, rayId, lastModified, eq.options.Clock.Now()))
 	records = append(records, eq.userEventQueue.BuildBatchRecords()...)
 	eq.aggEventQueue = make(AggregateEventQueue)
 	eq.userEventQueue = make(UserEventQueue)
@@ -497,17 +504,22 @@ func (eq *EventQueue) processAggregateEvent(event aggEventData) (err error) {
 		}
 		// Default events have no variation; only a static default flag to then aggregate by default reason.
 		// To make the aggregation mapping consistent later on when re-aggregating - it will result in a double aggregation of `[default][default][reason]` intentionally.
-		if _, ok := defaultReasonAggMap[string(api.EvaluationReasonDefault)]; !ok {
-			defaultReasonAggMap[string(api.EvaluationReasonDefault)] = make(EvalReasonAggMap)
+		// Defaults caused by an unmet prerequisite are aggregated separately, so they can be reported as such.
+		variation := string(api.EvaluationReasonDefault)
+		if event.defaultReason == api.DefaultReasonPrerequisiteNotMet {
+			variation = string(event.defaultReason)
+		}
+		if _, ok := defaultReasonAggMap[variation]; !ok {
+			defaultReasonAggMap[variation] = make(EvalReasonAggMap)
 		}
-		defaultReasons := defaultReasonAggMap[string(api.EvaluationReasonDefault)]
+		defaultReasons := defaultReasonAggMap[variation]
 		for reason, count := range event.eval {
 			if _, ok := defaultReasons[reason]; !ok {
 				defaultReasons[reason] = 0
 			}
 			defaultReasons[reason] += count
 		}
-		defaultReasonAggMap[string(api.EvaluationReasonDefault)] = defaultReasons
+		defaultReasonAggMap[variation] = defaultReasons
 		featureVariationAggregationMap[string(api.EvaluationReasonDefault)] = defaultReasonAggMap
 	}
 	variableFeatureVariationAggregationMap[eTarget] = featureVariationAggregationMap
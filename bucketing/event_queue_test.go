@@ -83,6 +83,44 @@ func TestEventQueue_ProcessAggregateEvent(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestEventQueue_PrerequisiteDefaultedEvents(t *testing.T) {
+	err := SetConfig(test_config, "dvc_server_token_hash", "", "", "")
+	require.NoError(t, err)
+	eq, err := NewEventQueue("dvc_server_token_hash", &api.EventQueueOptions{}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	reasons := []api.DefaultReason{
+		api.DefaultReasonPrerequisiteNotMet,
+		api.DefaultReasonPrerequisiteNotMet,
+		api.DefaultReasonUserNotTargeted,
+		api.DefaultReasonMissingVariable,
+	}
+	for _, reason := range reasons {
+		err = eq.processAggregateEvent(aggEventData{
+			eventType:     api.EventType_AggVariableDefaulted,
+			variableKey:   "somevariablekey",
+			defaultReason: reason,
+			eval:          eventEval{api.EvaluationReasonDefault: 1},
+		})
+		require.NoError(t, err)
+	}
+	require.Equal(t, VariationAggMap{
+		string(api.EvaluationReasonDefault):         {api.EvaluationReasonDefault: 2},
+		string(api.DefaultReasonPrerequisiteNotMet): {api.EvaluationReasonDefault: 2},
+	}, eq.aggEventQueue[api.EventType_AggVariableDefaulted]["somevariablekey"][string(api.EvaluationReasonDefault)],
+		"only unmet prerequisites are aggregated by their default reason")
+
+	record := eq.aggEventQueue.BuildBatchRecords((&api.PlatformData{}).Default(), "uuid", "", "", "", time.Now())
+	counts := make(map[interface{}]float64)
+	for _, event := range record.Events {
+		require.Equal(t, api.EvaluationReasonDefault, event.MetaData["_variation"])
+		counts[event.MetaData["defaultReason"]] += event.Value
+	}
+	require.Equal(t, map[interface{}]float64{
+		string(api.DefaultReasonPrerequisiteNotMet): 2,
+		nil: 2,
+	}, counts)
+}
+
 func TestEventQueue_AddToUserQueue(t *testing.T) {
 	event := api.Event{
 		Type_:      api.EventType_VariableEvaluated,
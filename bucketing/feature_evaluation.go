@@ -0,0 +1,109 @@
+package bucketing
+
+import (
+	"time"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// FeatureEvaluation is the outcome of bucketing a user into a feature.
+type FeatureEvaluation struct {
+	FeatureId  string
+	FeatureKey string
+	// TargetId is the target the user matched, unset if the feature defaulted
+	TargetId     string
+	VariationId  string
+	VariationKey string
+	// IsRollout is set when the user was served through the matched target's rollout
+	IsRollout  bool
+	EvalReason api.EvaluationReason
+	// DefaultReason explains why the feature defaulted, and is api.DefaultReasonNotDefaulted otherwise
+	DefaultReason api.DefaultReason
+	// Trace is only set by EvaluateFeaturesWithTrace
+	Trace []TargetTrace
+}
+
+// TargetTrace records how a feature's target was evaluated for a user, in target order up to
+// the target the user matched.
+type TargetTrace struct {
+	TargetId string
+	// Active is unset if the target's active window excludes the evaluation time, in which case
+	// nothing else about the target is evaluated
+	Active bool
+	// Rollout is set if the target's rollout was evaluated
+	Rollout *RolloutTrace
+	// AudienceMatched is only evaluated for users in the target's rollout
+	AudienceMatched bool
+}
+
+type RolloutTrace struct {
+	Percentage float64
+	Hash       float64
+	InRollout  bool
+}
+
+// traceTarget records the target's trace if the context is tracing.
+func (ctx *evaluationContext) traceTarget(feature *ConfigFeature, target *Target, trace TargetTrace) {
+	if ctx.targetTraces == nil {
+		return
+	}
+	if trace.Rollout != nil {
+		trace.Rollout.Percentage = getCurrentRolloutPercentage(*target.Rollout, ctx.now)
+	}
+	ctx.targetTraces[feature.Id] = append(ctx.targetTraces[feature.Id], trace)
+}
+
+// EvaluateFeatures buckets the user into every feature in the config for the sdk key at the given
+// time, the same way GenerateBucketedConfig does, but also reports the features the user isn't
+// served and why. Sticky assignments are read but never stored.
+func EvaluateFeatures(sdkKey string, user api.PopulatedUser, clientCustomData map[string]interface{}, at time.Time) ([]FeatureEvaluation, error) {
+	return evaluateFeatures(sdkKey, user, clientCustomData, at, false)
+}
+
+// EvaluateFeaturesWithTrace is EvaluateFeatures with a trace of each feature's targets.
+func EvaluateFeaturesWithTrace(sdkKey string, user api.PopulatedUser, clientCustomData map[string]interface{}, at time.Time) ([]FeatureEvaluation, error) {
+	return evaluateFeatures(sdkKey, user, clientCustomData, at, true)
+}
+
+func evaluateFeatures(sdkKey string, user api.PopulatedUser, clientCustomData map[string]interface{}, at time.Time, trace bool) ([]FeatureEvaluation, error) {
+	config, err := getConfig(sdkKey)
+	if err != nil {
+		return nil, err
+	}
+	ctx := newEvaluationContext(user, clientCustomData, at)
+	ctx.assignmentStore = getAssignmentStore(sdkKey)
+	if trace {
+		ctx.targetTraces = make(map[string][]TargetTrace)
+	}
+
+	evaluations := make([]FeatureEvaluation, 0, len(config.Features))
+	for _, feature := range config.Features {
+		evaluation := FeatureEvaluation{
+			FeatureId:  feature.Id,
+			FeatureKey: feature.Key,
+			EvalReason: api.EvaluationReasonDefault,
+		}
+		// Prerequisites of earlier features may have already traced this feature
+		delete(ctx.targetTraces, feature.Id)
+		thash, isRollout, err := doesUserQualifyForFeature(config, feature, ctx)
+		evaluation.Trace = ctx.targetTraces[feature.Id]
+		var variation *Variation
+		var isRandomDistrib, isSticky bool
+		if err == nil {
+			variation, isRandomDistrib, isSticky, err = decideVariation(ctx, feature, thash)
+		}
+		evaluation.DefaultReason = BucketResultErrorToDefaultReason(err)
+		if err != nil {
+			evaluations = append(evaluations, evaluation)
+			continue
+		}
+
+		evaluation.TargetId = thash.Target.Id
+		evaluation.VariationId = variation.Id
+		evaluation.VariationKey = variation.Key
+		evaluation.IsRollout = isRollout
+		evaluation.EvalReason = evaluationReason(thash, isRollout, isRandomDistrib, isSticky)
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, nil
+}
@@ -0,0 +1,67 @@
+package bucketing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+func TestEvaluateFeatures(t *testing.T) {
+	sdkKey := "dvc_server_evaluate_features"
+	config := prerequisiteConfig(t, []prerequisiteFeature{
+		{key: "everyone", variation: "on"},
+		{key: "targeted", userIds: []string{"someone-else"}, variation: "on"},
+	})
+	require.NoError(t, SetConfig(config, sdkKey, "", "", ""))
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	evaluations, err := EvaluateFeatures(sdkKey, user, nil, time.Now())
+	require.NoError(t, err)
+	require.Len(t, evaluations, 2)
+
+	require.Equal(t, "everyone", evaluations[0].FeatureKey)
+	require.NotEmpty(t, evaluations[0].TargetId)
+	require.Equal(t, "on", evaluations[0].VariationKey)
+	require.Equal(t, api.EvaluationReasonTargetingMatch, evaluations[0].EvalReason)
+	require.Equal(t, api.DefaultReasonNotDefaulted, evaluations[0].DefaultReason)
+
+	require.Equal(t, "targeted", evaluations[1].FeatureKey)
+	require.Empty(t, evaluations[1].TargetId)
+	require.Equal(t, api.EvaluationReasonDefault, evaluations[1].EvalReason)
+	require.Equal(t, api.DefaultReasonUserNotTargeted, evaluations[1].DefaultReason)
+
+	_, err = EvaluateFeatures("dvc_server_missing", user, nil, time.Now())
+	require.Error(t, err)
+}
+
+func TestEvaluateFeaturesWithTrace(t *testing.T) {
+	sdkKey := "dvc_server_evaluate_features_trace"
+	require.NoError(t, SetConfig(activeWindowConfig(t, &TimeWindow{Start: scheduleTime(0), End: scheduleTime(24)}), sdkKey, "", "", ""))
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	evaluations, err := EvaluateFeaturesWithTrace(sdkKey, user, nil, *scheduleTime(-1))
+	require.NoError(t, err)
+	require.Len(t, evaluations[0].Trace, 2)
+	require.False(t, evaluations[0].Trace[0].Active, "the first target is outside its active window")
+	require.Equal(t, TargetTrace{TargetId: "fallback", Active: true, AudienceMatched: true}, evaluations[0].Trace[1])
+	require.Equal(t, "fallback", evaluations[0].TargetId)
+
+	evaluations, err = EvaluateFeatures(sdkKey, user, nil, *scheduleTime(-1))
+	require.NoError(t, err)
+	require.Nil(t, evaluations[0].Trace)
+
+	sdkKey = "dvc_server_evaluate_features_trace_rollout"
+	require.NoError(t, SetConfig(rolloutConfig(t, Rollout{Type: "schedule", StartDate: scheduleStart}), sdkKey, "", "", ""))
+	evaluations, err = EvaluateFeaturesWithTrace(sdkKey, user, nil, *scheduleTime(-1))
+	require.NoError(t, err)
+	require.Len(t, evaluations[0].Trace, 1)
+	trace := evaluations[0].Trace[0]
+	require.True(t, trace.Active)
+	require.False(t, trace.AudienceMatched)
+	require.Equal(t, 0.0, trace.Rollout.Percentage)
+	require.False(t, trace.Rollout.InRollout)
+	require.Equal(t, api.DefaultReasonUserNotTargeted, evaluations[0].DefaultReason)
+}
@@ -0,0 +1,234 @@
+package bucketing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// FeaturePrerequisiteProblem is a prerequisite filter that can't be evaluated, either because
+// the prerequisite feature doesn't exist or because it is part of a prerequisite cycle.
+type FeaturePrerequisiteProblem struct {
+	// Feature is the key of the feature with the prerequisite
+	Feature string
+	// Prerequisite is the id of the missing feature, unset for cycles
+	Prerequisite string
+	// Cycle is set when the feature depends on itself, e.g. [a b a] for a -> b -> a
+	Cycle []string
+}
+
+func (p FeaturePrerequisiteProblem) String() string {
+	if len(p.Cycle) > 0 {
+		return fmt.Sprintf("feature prerequisite cycle %s", strings.Join(p.Cycle, " -> "))
+	}
+	return fmt.Sprintf("missing prerequisite feature %s (feature: %s)", p.Prerequisite, p.Feature)
+}
+
+// FeaturePrerequisiteError is returned when a config contains prerequisite filters that can't be
+// resolved.
+type FeaturePrerequisiteError struct {
+	Problems []FeaturePrerequisiteProblem
+}
+
+func (e *FeaturePrerequisiteError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.String()
+	}
+	return "invalid feature prerequisites: " + strings.Join(problems, "; ")
+}
+
+// Features returns the keys of every feature affected by a problem.
+func (e *FeaturePrerequisiteError) Features() []string {
+	seen := make(map[string]bool)
+	var features []string
+	for _, problem := range e.Problems {
+		affected := problem.Cycle
+		if len(affected) == 0 {
+			affected = []string{problem.Feature}
+		}
+		for _, feature := range affected {
+			if !seen[feature] {
+				seen[feature] = true
+				features = append(features, feature)
+			}
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// PrerequisiteFilter passes when the user is served (=) or not served (!=) the prerequisite
+// feature. If a variation is set, only that variation of the feature counts as being served.
+type PrerequisiteFilter struct {
+	filter
+	Feature   string `json:"_feature"`
+	Variation string `json:"_variation,omitempty"`
+}
+
+func (f *PrerequisiteFilter) Initialize() error {
+	if f.Feature == "" {
+		return fmt.Errorf("prerequisite filter requires a feature")
+	}
+	if f.Comparator != ComparatorEqual && f.Comparator != ComparatorNotEqual {
+		return fmt.Errorf("prerequisite filter comparator must be = or !=. Got: %s", f.Comparator)
+	}
+	return nil
+}
+
+// Evaluate never passes, as the prerequisite feature can only be bucketed with its config.
+// Bucketing evaluates prerequisite filters through compiled targets instead.
+func (f *PrerequisiteFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
+	return false
+}
+
+func (f PrerequisiteFilter) Type() string {
+	return TypePrerequisite
+}
+
+func collectPrerequisiteFilters(f FilterOrOperator, filters []*PrerequisiteFilter) []*PrerequisiteFilter {
+	switch filter := f.(type) {
+	case *AudienceOperator:
+		if filter != nil {
+			for _, nested := range filter.Filters {
+				filters = collectPrerequisiteFilters(nested, filters)
+			}
+		}
+	case AudienceOperator:
+		for _, nested := range filter.Filters {
+			filters = collectPrerequisiteFilters(nested, filters)
+		}
+	case *PrerequisiteFilter:
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// featurePrerequisiteFilters returns the prerequisite filters of the feature's targets, including
+// those of the audiences they reference.
+func featurePrerequisiteFilters(config *configBody, feature *ConfigFeature) []*PrerequisiteFilter {
+	var filters []*PrerequisiteFilter
+	var references []string
+	for _, target := range feature.Configuration.Targets {
+		if target.Audience == nil {
+			continue
+		}
+		filters = collectPrerequisiteFilters(target.Audience.Filters, filters)
+		references = collectAudienceReferences(target.Audience.Filters, references)
+	}
+	visited := make(map[string]bool)
+	for len(references) > 0 {
+		id := references[len(references)-1]
+		references = references[:len(references)-1]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if audience, ok := config.Audiences[id]; ok {
+			filters = collectPrerequisiteFilters(audience.Filters, filters)
+			references = collectAudienceReferences(audience.Filters, references)
+		}
+	}
+	return filters
+}
+
+// validateFeaturePrerequisites returns a FeaturePrerequisiteError if any feature has a
+// prerequisite filter on a feature that doesn't exist, or depends on itself through its
+// prerequisites.
+func validateFeaturePrerequisites(config *configBody) error {
+	features := make(map[string]*ConfigFeature, len(config.Features))
+	prerequisites := make(map[string][]*PrerequisiteFilter, len(config.Features))
+	for _, feature := range config.Features {
+		features[feature.Id] = feature
+		prerequisites[feature.Id] = featurePrerequisiteFilters(config, feature)
+	}
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(features))
+	var problems []FeaturePrerequisiteProblem
+	var path []string
+
+	var visit func(feature *ConfigFeature)
+	visit = func(feature *ConfigFeature) {
+		state[feature.Id] = inProgress
+		path = append(path, feature.Key)
+		for _, prerequisite := range prerequisites[feature.Id] {
+			dependency, ok := features[prerequisite.Feature]
+			if !ok {
+				problems = append(problems, FeaturePrerequisiteProblem{Feature: feature.Key, Prerequisite: prerequisite.Feature})
+				continue
+			}
+			switch state[dependency.Id] {
+			case unvisited:
+				visit(dependency)
+			case inProgress:
+				start := len(path) - 1
+				for path[start] != dependency.Key {
+					start--
+				}
+				cycle := append(append([]string{}, path[start:]...), dependency.Key)
+				problems = append(problems, FeaturePrerequisiteProblem{Cycle: cycle})
+			}
+		}
+		path = path[:len(path)-1]
+		state[feature.Id] = done
+	}
+	for _, feature := range config.Features {
+		if state[feature.Id] == unvisited {
+			visit(feature)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &FeaturePrerequisiteError{Problems: problems}
+}
+
+// prerequisitesMet reports whether the user meets every prerequisite filter of the feature's
+// targets. It explains why a user didn't qualify for any target.
+func prerequisitesMet(config *configBody, feature *ConfigFeature, ctx *evaluationContext) bool {
+	for _, prerequisite := range config.featurePrerequisites[feature.Id] {
+		if !prerequisiteMet(config, prerequisite, ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func prerequisiteMet(config *configBody, prerequisite *PrerequisiteFilter, ctx *evaluationContext) bool {
+	variation := ctx.prerequisiteVariation(config, prerequisite.Feature)
+	served := variation != nil && (prerequisite.Variation == "" || variation.Id == prerequisite.Variation)
+	return served == (prerequisite.Comparator == ComparatorEqual)
+}
+
+// prerequisiteVariation buckets the user into the feature with the given id, returning nil if the
+// user isn't served the feature. Results are memoized for the rest of the evaluation, and a
+// feature that depends on itself is never served.
+func (ctx *evaluationContext) prerequisiteVariation(config *configBody, featureId string) *Variation {
+	if variation, ok := ctx.prerequisiteVariations[featureId]; ok {
+		return variation
+	}
+	feature := config.GetFeatureForId(featureId)
+	if feature == nil {
+		return nil
+	}
+	if ctx.prerequisiteVariations == nil {
+		ctx.prerequisiteVariations = make(map[string]*Variation)
+	}
+	// Stored up front so that a cycle resolves to not served
+	ctx.prerequisiteVariations[featureId] = nil
+
+	var variation *Variation
+	if hashes, _, err := doesUserQualifyForFeature(config, feature, ctx); err == nil {
+		variation, _, _, _ = decideVariation(ctx, feature, hashes)
+	}
+	ctx.prerequisiteVariations[featureId] = variation
+	return variation
+}
@@ -0,0 +1,245 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+type prerequisiteFeature struct {
+	key string
+	// userIds limits the feature's target to these users, or targets everyone if empty
+	userIds       []string
+	variation     string
+	prerequisites []prerequisiteFilter
+}
+
+// prerequisiteFilter is added to the audience of a prerequisiteFeature's target.
+type prerequisiteFilter struct {
+	feature    string
+	variation  string
+	comparator string
+}
+
+func (p prerequisiteFilter) filter() map[string]interface{} {
+	filter := map[string]interface{}{"type": "prerequisite", "comparator": p.comparator, "_feature": p.feature}
+	if p.variation != "" {
+		filter["_variation"] = p.variation
+	}
+	return filter
+}
+
+// prerequisiteConfig builds a config JSON where each feature has a single target serving one
+// variation, and a boolean variable keyed "var-<feature key>".
+func prerequisiteConfig(t *testing.T, features []prerequisiteFeature) []byte {
+	var configFeatures, configVariables []interface{}
+	for _, feature := range features {
+		audienceFilter := map[string]interface{}{"type": "all"}
+		if len(feature.userIds) > 0 {
+			audienceFilter = map[string]interface{}{"type": "user", "subType": "user_id", "comparator": "=", "values": feature.userIds}
+		}
+		audienceFilters := []interface{}{audienceFilter}
+		for _, prerequisite := range feature.prerequisites {
+			audienceFilters = append(audienceFilters, prerequisite.filter())
+		}
+		var variations []interface{}
+		for _, variationId := range []string{"on", "off"} {
+			variations = append(variations, map[string]interface{}{
+				"_id":       variationId,
+				"key":       variationId,
+				"variables": []interface{}{map[string]interface{}{"_var": "var-" + feature.key, "value": variationId == "on"}},
+			})
+		}
+		configFeatures = append(configFeatures, map[string]interface{}{
+			"_id":        feature.key,
+			"key":        feature.key,
+			"type":       "release",
+			"variations": variations,
+			"configuration": map[string]interface{}{
+				"_id": "config-" + feature.key,
+				"targets": []interface{}{map[string]interface{}{
+					"_id":          "target-" + feature.key,
+					"_audience":    map[string]interface{}{"_id": "audience", "filters": map[string]interface{}{"operator": "and", "filters": audienceFilters}},
+					"distribution": []interface{}{map[string]interface{}{"_variation": feature.variation, "percentage": 1}},
+				}},
+			},
+		})
+		configVariables = append(configVariables, map[string]interface{}{"_id": "var-" + feature.key, "key": "var-" + feature.key, "type": "Boolean"})
+	}
+	config, err := json.Marshal(map[string]interface{}{
+		"project":     map[string]interface{}{"_id": "project", "key": "project", "a0_organization": "org", "settings": map[string]interface{}{}},
+		"environment": map[string]interface{}{"_id": "environment", "key": "environment"},
+		"features":    configFeatures,
+		"variables":   configVariables,
+	})
+	require.NoError(t, err)
+	return config
+}
+
+func TestNewConfig_FeaturePrerequisites(t *testing.T) {
+	testCases := []struct {
+		name             string
+		features         []prerequisiteFeature
+		expectedProblems []FeaturePrerequisiteProblem
+		expectedFeatures []string
+	}{
+		{
+			name: "valid prerequisites",
+			features: []prerequisiteFeature{
+				{key: "a", variation: "on", prerequisites: []prerequisiteFilter{{feature: "b", comparator: "="}, {feature: "c", comparator: "!="}}},
+				{key: "b", variation: "on", prerequisites: []prerequisiteFilter{{feature: "c", comparator: "="}}},
+				{key: "c", variation: "on"},
+			},
+		},
+		{
+			name: "self prerequisite",
+			features: []prerequisiteFeature{
+				{key: "a", variation: "on", prerequisites: []prerequisiteFilter{{feature: "a", comparator: "="}}},
+			},
+			expectedProblems: []FeaturePrerequisiteProblem{{Cycle: []string{"a", "a"}}},
+			expectedFeatures: []string{"a"},
+		},
+		{
+			name: "indirect cycle",
+			features: []prerequisiteFeature{
+				{key: "d", variation: "on", prerequisites: []prerequisiteFilter{{feature: "b", comparator: "="}}},
+				{key: "a", variation: "on", prerequisites: []prerequisiteFilter{{feature: "c", comparator: "!="}}},
+				{key: "b", variation: "on", prerequisites: []prerequisiteFilter{{feature: "c", comparator: "="}}},
+				{key: "c", variation: "on", prerequisites: []prerequisiteFilter{{feature: "a", comparator: "="}}},
+			},
+			expectedProblems: []FeaturePrerequisiteProblem{{Cycle: []string{"c", "a", "c"}}},
+			expectedFeatures: []string{"a", "c"},
+		},
+		{
+			name: "missing prerequisite feature",
+			features: []prerequisiteFeature{
+				{key: "a", variation: "on", prerequisites: []prerequisiteFilter{{feature: "missing", comparator: "="}}},
+				{key: "b", variation: "on"},
+			},
+			expectedProblems: []FeaturePrerequisiteProblem{{Feature: "a", Prerequisite: "missing"}},
+			expectedFeatures: []string{"a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := newConfig(prerequisiteConfig(t, tc.features), "", "", "")
+			if len(tc.expectedProblems) == 0 {
+				require.NoError(t, err)
+				require.NotNil(t, config)
+				return
+			}
+			var prerequisiteErr *FeaturePrerequisiteError
+			require.ErrorAs(t, err, &prerequisiteErr)
+			require.Equal(t, tc.expectedProblems, prerequisiteErr.Problems)
+			require.Equal(t, tc.expectedFeatures, prerequisiteErr.Features())
+		})
+	}
+}
+
+func TestNewConfig_PrerequisiteInAudience(t *testing.T) {
+	audienceConfig := func(t *testing.T, prerequisite string) []byte {
+		var config map[string]interface{}
+		require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{
+			{key: "a", userIds: []string{"served-on"}, variation: "on"},
+			{key: "b", variation: "on"},
+		}), &config))
+		config["audiences"] = map[string]interface{}{
+			"needs-prerequisite": map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{
+				prerequisiteFilter{feature: prerequisite, comparator: "="}.filter(),
+			}}},
+		}
+		target := config["features"].([]interface{})[1].(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+		setTargetFilter(target, map[string]interface{}{"type": "audienceMatch", "comparator": "=", "_audiences": []interface{}{"needs-prerequisite"}})
+		configJSON, err := json.Marshal(config)
+		require.NoError(t, err)
+		return configJSON
+	}
+
+	config, err := newConfig(audienceConfig(t, "a"), "", "", "")
+	require.NoError(t, err)
+	for userId, expectedErr := range map[string]error{"served-on": nil, "someone-else": ErrPrerequisiteNotMet} {
+		user := api.User{UserId: userId}.GetPopulatedUser(&api.PlatformData{})
+		_, _, err = doesUserQualifyForFeature(config, config.GetFeatureForId("b"), newEvaluationContext(user, nil, time.Now()))
+		require.Equal(t, expectedErr, err, userId)
+	}
+
+	_, err = newConfig(audienceConfig(t, "b"), "", "", "")
+	var prerequisiteErr *FeaturePrerequisiteError
+	require.ErrorAs(t, err, &prerequisiteErr)
+	require.Equal(t, []FeaturePrerequisiteProblem{{Cycle: []string{"b", "b"}}}, prerequisiteErr.Problems)
+}
+
+func TestMixedFilters_PrerequisiteFilter(t *testing.T) {
+	var filters MixedFilters
+	require.NoError(t, json.Unmarshal([]byte(`[{"type": "prerequisite", "comparator": "!=", "_feature": "a", "_variation": "on"}]`), &filters))
+	require.Equal(t, MixedFilters{&PrerequisiteFilter{filter: filter{Type: TypePrerequisite, Comparator: "!="}, Feature: "a", Variation: "on"}}, filters)
+
+	require.Error(t, json.Unmarshal([]byte(`[{"type": "prerequisite", "comparator": ">", "_feature": "a"}]`), &filters))
+	require.Error(t, json.Unmarshal([]byte(`[{"type": "prerequisite", "comparator": "="}]`), &filters))
+}
+
+func TestDoesUserQualifyForFeature_Prerequisites(t *testing.T) {
+	base := prerequisiteFeature{key: "base", userIds: []string{"served-on"}, variation: "on"}
+	testCases := []struct {
+		name         string
+		prerequisite prerequisiteFilter
+		userId       string
+		expectedErr  error
+	}{
+		{name: "served prerequisite", prerequisite: prerequisiteFilter{feature: "base", comparator: "="}, userId: "served-on"},
+		{name: "not served prerequisite", prerequisite: prerequisiteFilter{feature: "base", comparator: "="}, userId: "someone-else", expectedErr: ErrPrerequisiteNotMet},
+		{name: "served prerequisite variation", prerequisite: prerequisiteFilter{feature: "base", variation: "on", comparator: "="}, userId: "served-on"},
+		{name: "served other variation", prerequisite: prerequisiteFilter{feature: "base", variation: "off", comparator: "="}, userId: "served-on", expectedErr: ErrPrerequisiteNotMet},
+		{name: "not served excluded feature", prerequisite: prerequisiteFilter{feature: "base", comparator: "!="}, userId: "someone-else"},
+		{name: "served excluded feature", prerequisite: prerequisiteFilter{feature: "base", comparator: "!="}, userId: "served-on", expectedErr: ErrPrerequisiteNotMet},
+		{name: "served other than excluded variation", prerequisite: prerequisiteFilter{feature: "base", variation: "off", comparator: "!="}, userId: "served-on"},
+		{name: "nested prerequisite not met", prerequisite: prerequisiteFilter{feature: "middle", comparator: "="}, userId: "someone-else", expectedErr: ErrPrerequisiteNotMet},
+		{name: "nested prerequisite met", prerequisite: prerequisiteFilter{feature: "middle", comparator: "="}, userId: "served-on"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := newConfig(prerequisiteConfig(t, []prerequisiteFeature{
+				base,
+				{key: "middle", variation: "on", prerequisites: []prerequisiteFilter{{feature: "base", comparator: "="}}},
+				{key: "dependent", variation: "on", prerequisites: []prerequisiteFilter{tc.prerequisite}},
+			}), "", "", "")
+			require.NoError(t, err)
+
+			user := api.User{UserId: tc.userId}.GetPopulatedUser(&api.PlatformData{})
+			_, _, err = doesUserQualifyForFeature(config, config.GetFeatureForId("dependent"), newEvaluationContext(user, nil, time.Now()))
+			require.Equal(t, tc.expectedErr, err)
+			_, _, err = doesUserQualifyForFeature(withoutCompiledTargets(config), config.GetFeatureForId("dependent"), newEvaluationContext(user, nil, time.Now()))
+			require.Equal(t, tc.expectedErr, err, "interpreted evaluation")
+		})
+	}
+}
+
+func TestVariableForUser_PrerequisiteNotMet(t *testing.T) {
+	sdkKey := "dvc_server_prerequisites"
+	err := SetConfig(prerequisiteConfig(t, []prerequisiteFeature{
+		{key: "base", userIds: []string{"served-on"}, variation: "on"},
+		{key: "dependent", variation: "on", prerequisites: []prerequisiteFilter{{feature: "base", variation: "on", comparator: "="}}},
+	}), sdkKey, "", "", "")
+	require.NoError(t, err)
+	eventQueue, err := NewEventQueue(sdkKey, &api.EventQueueOptions{DisableAutomaticEventLogging: true}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer func() { _ = eventQueue.Close() }()
+
+	user := api.User{UserId: "someone-else"}.GetPopulatedUser(&api.PlatformData{})
+	_, _, _, evalReason, evalDetails, err := VariableForUser(sdkKey, user, "var-dependent", VariableTypesBool, eventQueue, nil)
+	require.ErrorIs(t, err, ErrPrerequisiteNotMet)
+	require.Equal(t, api.EvaluationReasonDefault, evalReason)
+	require.Equal(t, string(api.DefaultReasonPrerequisiteNotMet), evalDetails)
+
+	user = api.User{UserId: "served-on"}.GetPopulatedUser(&api.PlatformData{})
+	_, value, _, evalReason, _, err := VariableForUser(sdkKey, user, "var-dependent", VariableTypesBool, eventQueue, nil)
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+	require.Equal(t, api.EvaluationReasonTargetingMatch, evalReason)
+}
@@ -0,0 +1,67 @@
+package bucketing
+
+import (
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// Held out users are served the variation with this key, unless the holdout sets its own.
+const defaultControlVariationKey = "control"
+
+const holdoutTargetId = "holdout"
+
+var defaultHoldoutFeatureTypes = []string{"experiment"}
+
+// holdoutIncludes reports whether the project holdout applies to the feature.
+func holdoutIncludes(holdout *api.HoldoutSettings, feature *ConfigFeature) bool {
+	if holdout == nil || holdout.Percentage <= 0 {
+		return false
+	}
+	featureTypes := holdout.FeatureTypes
+	if len(featureTypes) == 0 {
+		featureTypes = defaultHoldoutFeatureTypes
+	}
+	for _, featureType := range featureTypes {
+		if featureType == feature.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// isUserInHoldout reports whether the user with the given bucketing value is held out by the
+// project holdout. Callers check holdoutIncludes first.
+func isUserInHoldout(holdout *api.HoldoutSettings, bucketingValue string) bool {
+	return generateBoundedHash(bucketingValue, murmurhashV3(holdout.Salt, baseSeed)) < holdout.Percentage
+}
+
+// holdoutBucketingValue returns the value the user is held out by.
+func holdoutBucketingValue(holdout *api.HoldoutSettings, ctx *evaluationContext) string {
+	if holdout == nil || isUserIdBucketingKey(holdout.BucketingKey) {
+		return ctx.user.UserId
+	}
+	target := &Target{BucketingKey: holdout.BucketingKey, BucketingKeyFallbacks: []string{"user_id"}}
+	value, _ := resolveBucketingValue(target, ctx.user.UserId, ctx.mergedCustomData)
+	return value
+}
+
+func controlVariationKey(holdout *api.HoldoutSettings) string {
+	if holdout == nil || holdout.ControlVariation == "" {
+		return defaultControlVariationKey
+	}
+	return holdout.ControlVariation
+}
+
+// holdoutTarget returns a target serving the feature's control variation to every user, or nil
+// if the feature has no control variation.
+func holdoutTarget(holdout *api.HoldoutSettings, feature *ConfigFeature) *Target {
+	controlKey := controlVariationKey(holdout)
+	for _, variation := range feature.Variations {
+		if variation.Key == controlKey {
+			return &Target{
+				Id:           holdoutTargetId,
+				Distribution: []TargetDistribution{{Variation: variation.Id, Percentage: 1}},
+			}
+		}
+	}
+	return nil
+}
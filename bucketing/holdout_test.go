@@ -0,0 +1,148 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// holdoutConfig builds a config JSON with the given project holdout, an "experiment" feature whose
+// "off" variation is keyed "control", an "experiment-without-control" feature, and a "release"
+// feature. Every feature targets everyone with its "on" variation.
+func holdoutConfig(t *testing.T, holdout *api.HoldoutSettings) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{
+		{key: "experiment", variation: "on"},
+		{key: "experiment-without-control", variation: "on"},
+		{key: "release", variation: "on"},
+	}), &config))
+	config["project"].(map[string]interface{})["settings"] = map[string]interface{}{"holdout": holdout}
+	for _, f := range config["features"].([]interface{}) {
+		feature := f.(map[string]interface{})
+		if feature["key"] == "release" {
+			continue
+		}
+		feature["type"] = "experiment"
+		if feature["key"] == "experiment" {
+			feature["variations"].([]interface{})[1].(map[string]interface{})["key"] = defaultControlVariationKey
+		}
+	}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestHoldoutIncludes(t *testing.T) {
+	experiment := &ConfigFeature{Type: "experiment"}
+	release := &ConfigFeature{Type: "release"}
+
+	require.True(t, holdoutIncludes(&api.HoldoutSettings{Percentage: 0.1}, experiment))
+	require.False(t, holdoutIncludes(&api.HoldoutSettings{Percentage: 0.1}, release))
+	require.False(t, holdoutIncludes(&api.HoldoutSettings{Percentage: 0}, experiment))
+	require.False(t, holdoutIncludes(nil, experiment))
+
+	holdout := &api.HoldoutSettings{Percentage: 1, FeatureTypes: []string{"release"}}
+	require.True(t, holdoutIncludes(holdout, release))
+	require.False(t, holdoutIncludes(holdout, experiment))
+}
+
+func TestIsUserInHoldout(t *testing.T) {
+	const users = 5000
+	heldOut := 0
+	heldOutWithOtherSalt := 0
+	for i := 0; i < users; i++ {
+		userId := fmt.Sprintf("user-%d", i)
+		if isUserInHoldout(&api.HoldoutSettings{Percentage: 0.1, Salt: "salt"}, userId) {
+			heldOut++
+		}
+		if isUserInHoldout(&api.HoldoutSettings{Percentage: 0.1, Salt: "other-salt"}, userId) {
+			heldOutWithOtherSalt++
+		}
+	}
+	require.InDelta(t, 0.1, float64(heldOut)/users, 0.02)
+	require.InDelta(t, 0.1, float64(heldOutWithOtherSalt)/users, 0.02)
+
+	require.True(t, isUserInHoldout(&api.HoldoutSettings{Percentage: 1}, "user"))
+}
+
+func TestVariableForUser_Holdout(t *testing.T) {
+	sdkKey := "dvc_server_holdout"
+	require.NoError(t, SetConfig(holdoutConfig(t, &api.HoldoutSettings{Percentage: 1, Salt: "holdout"}), sdkKey, "", "", ""))
+	eventQueue, err := NewEventQueue(sdkKey, &api.EventQueueOptions{FlushEventsInterval: time.Hour}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer func() { _ = eventQueue.Close() }()
+	user := api.User{UserId: "held-out"}.GetPopulatedUser(&api.PlatformData{})
+
+	_, value, _, evalReason, _, err := VariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, eventQueue, nil)
+	require.NoError(t, err)
+	require.Equal(t, false, value, "held out users are served the control variation")
+	require.Equal(t, api.EvaluationReasonHoldout, evalReason)
+
+	_, _, _, evalReason, evalDetails, err := VariableForUser(sdkKey, user, "var-experiment-without-control", VariableTypesBool, eventQueue, nil)
+	require.ErrorIs(t, err, ErrUserInHoldout)
+	require.Equal(t, api.EvaluationReasonDefault, evalReason)
+	require.Equal(t, string(api.DefaultReasonUserInHoldout), evalDetails)
+
+	_, value, _, evalReason, _, err = VariableForUser(sdkKey, user, "var-release", VariableTypesBool, eventQueue, nil)
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+	require.Equal(t, api.EvaluationReasonTargetingMatch, evalReason)
+
+	require.Eventually(t, func() bool {
+		eventQueue.queueAccess.RLock()
+		defer eventQueue.queueAccess.RUnlock()
+		features := eventQueue.aggEventQueue[api.EventType_AggVariableEvaluated]["var-experiment"]
+		return features["experiment"]["off"][api.EvaluationReasonHoldout] == 1
+	}, time.Second, time.Millisecond)
+
+	bucketedConfig, err := GenerateBucketedConfig(sdkKey, user, nil)
+	require.NoError(t, err)
+	require.Equal(t, "off", bucketedConfig.Features["experiment"].Variation)
+	require.Equal(t, api.EvaluationReasonHoldout, bucketedConfig.Variables["var-experiment"].Eval.Reason)
+	require.NotContains(t, bucketedConfig.Features, "experiment-without-control")
+	require.Equal(t, api.EvaluationReasonTargetingMatch, bucketedConfig.Variables["var-release"].Eval.Reason)
+}
+
+func TestVariableForUser_HoldoutSettings(t *testing.T) {
+	sdkKey := "dvc_server_holdout_settings"
+	holdout := &api.HoldoutSettings{Percentage: 1, Salt: "holdout", ControlVariation: "off"}
+	require.NoError(t, SetConfig(holdoutConfig(t, holdout), sdkKey, "", "", ""))
+	eventQueue, err := NewEventQueue(sdkKey, &api.EventQueueOptions{FlushEventsInterval: time.Hour}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer func() { _ = eventQueue.Close() }()
+	user := api.User{UserId: "held-out"}.GetPopulatedUser(&api.PlatformData{})
+
+	_, value, _, evalReason, _, err := VariableForUser(sdkKey, user, "var-experiment-without-control", VariableTypesBool, eventQueue, nil)
+	require.NoError(t, err)
+	require.Equal(t, false, value, "held out users are served the configured control variation")
+	require.Equal(t, api.EvaluationReasonHoldout, evalReason)
+
+	_, _, _, _, _, err = VariableForUser(sdkKey, user, "var-experiment", VariableTypesBool, eventQueue, nil)
+	require.ErrorIs(t, err, ErrUserInHoldout, "the default control variation key is no longer used")
+}
+
+func TestHoldoutBucketingValue(t *testing.T) {
+	holdout := &api.HoldoutSettings{Percentage: 0.5, Salt: "salt", BucketingKey: "account_id"}
+	heldOut := make(map[bool]int)
+	for i := 0; i < 20; i++ {
+		account := fmt.Sprintf("account-%d", i)
+		results := make(map[bool]bool)
+		for j := 0; j < 10; j++ {
+			user := api.User{UserId: fmt.Sprintf("user-%d-%d", i, j), CustomData: map[string]interface{}{"account_id": account}}.GetPopulatedUser(&api.PlatformData{})
+			results[isUserInHoldout(holdout, holdoutBucketingValue(holdout, newEvaluationContext(user, nil, time.Now())))] = true
+		}
+		require.Len(t, results, 1, "users of an account are held out together")
+		for result := range results {
+			heldOut[result]++
+		}
+	}
+	require.Len(t, heldOut, 2)
+
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+	require.Equal(t, "user", holdoutBucketingValue(holdout, newEvaluationContext(user, nil, time.Now())), "users without the key are held out by user id")
+}
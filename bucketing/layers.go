@@ -0,0 +1,72 @@
+package bucketing
+
+import (
+	"fmt"
+	"sort"
+)
+
+// featureLayer is the allocation of a feature within its layer.
+type featureLayer struct {
+	layer      *Layer
+	allocation *LayerAllocation
+}
+
+// validateLayers checks that every layer allocates existing features, that no feature is
+// allocated more than once, and that the allocations within a layer don't overlap.
+func validateLayers(config *configBody) error {
+	features := make(map[string]bool, len(config.Features))
+	for _, feature := range config.Features {
+		features[feature.Id] = true
+	}
+
+	layers := make(map[string]bool, len(config.Layers))
+	allocatedFeatures := make(map[string]string)
+	for _, layer := range config.Layers {
+		if layers[layer.Id] {
+			return fmt.Errorf("duplicate layer %s", layer.Id)
+		}
+		layers[layer.Id] = true
+
+		allocations := append([]*LayerAllocation{}, layer.Allocations...)
+		sort.SliceStable(allocations, func(i, j int) bool {
+			return allocations[i].Start < allocations[j].Start
+		})
+		for i, allocation := range allocations {
+			if !features[allocation.Feature] {
+				return fmt.Errorf("layer %s allocates missing feature %s", layer.Id, allocation.Feature)
+			}
+			if otherLayer, ok := allocatedFeatures[allocation.Feature]; ok {
+				return fmt.Errorf("feature %s is allocated by layers %s and %s", allocation.Feature, otherLayer, layer.Id)
+			}
+			allocatedFeatures[allocation.Feature] = layer.Id
+			if i > 0 && allocations[i-1].End > allocation.Start {
+				return fmt.Errorf("layer %s allocations for features %s and %s overlap", layer.Id, allocations[i-1].Feature, allocation.Feature)
+			}
+		}
+	}
+	return nil
+}
+
+// userInLayerAllocation reports whether the user's hash for the feature's layer falls within the
+// feature's allocation. Features that aren't part of a layer are always allocated.
+func userInLayerAllocation(config *configBody, feature *ConfigFeature, ctx *evaluationContext) bool {
+	featureLayer, ok := config.featureLayers[feature.Id]
+	if !ok {
+		return true
+	}
+	return featureLayer.allocation.contains(ctx.layerHash(featureLayer.layer))
+}
+
+// layerHash returns the user's bounded hash for the layer, which is computed once per evaluation
+// and shared by every feature in the layer.
+func (ctx *evaluationContext) layerHash(layer *Layer) float64 {
+	if hash, ok := ctx.layerHashes[layer.Id]; ok {
+		return hash
+	}
+	if ctx.layerHashes == nil {
+		ctx.layerHashes = make(map[string]float64)
+	}
+	hash := generateBoundedHash(ctx.user.UserId, murmurhashV3(layer.Id, baseSeed))
+	ctx.layerHashes[layer.Id] = hash
+	return hash
+}
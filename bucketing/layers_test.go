@@ -0,0 +1,135 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// layerConfig builds a config JSON with a feature targeting everyone for each key, and the given
+// layers.
+func layerConfig(t *testing.T, featureKeys []string, layers []*Layer) []byte {
+	var features []prerequisiteFeature
+	for _, key := range featureKeys {
+		features = append(features, prerequisiteFeature{key: key, variation: "on"})
+	}
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, features), &config))
+	config["layers"] = layers
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestNewConfig_Layers(t *testing.T) {
+	testCases := []struct {
+		name        string
+		allocations []*LayerAllocation
+		expectError bool
+	}{
+		{
+			name: "valid allocations",
+			allocations: []*LayerAllocation{
+				{Feature: "b", Start: 0.5, End: 1},
+				{Feature: "a", Start: 0, End: 0.5},
+			},
+		},
+		{
+			name: "overlapping allocations",
+			allocations: []*LayerAllocation{
+				{Feature: "a", Start: 0, End: 0.6},
+				{Feature: "b", Start: 0.5, End: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "feature allocated twice",
+			allocations: []*LayerAllocation{
+				{Feature: "a", Start: 0, End: 0.2},
+				{Feature: "a", Start: 0.5, End: 0.7},
+			},
+			expectError: true,
+		},
+		{
+			name:        "missing feature",
+			allocations: []*LayerAllocation{{Feature: "missing", Start: 0, End: 0.5}},
+			expectError: true,
+		},
+		{
+			name:        "empty range",
+			allocations: []*LayerAllocation{{Feature: "a", Start: 0.5, End: 0.5}},
+			expectError: true,
+		},
+		{
+			name:        "range out of bounds",
+			allocations: []*LayerAllocation{{Feature: "a", Start: 0.5, End: 1.5}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newConfig(layerConfig(t, []string{"a", "b"}, []*Layer{{Id: "layer", Allocations: tc.allocations}}), "", "", "")
+			if tc.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := newConfig(layerConfig(t, []string{"a", "b"}, []*Layer{
+		{Id: "layer-1", Allocations: []*LayerAllocation{{Feature: "a", Start: 0, End: 1}}},
+		{Id: "layer-2", Allocations: []*LayerAllocation{{Feature: "a", Start: 0, End: 1}}},
+	}), "", "", "")
+	require.Error(t, err, "a feature can only belong to one layer")
+}
+
+func TestGenerateBucketedConfig_LayerExclusivity(t *testing.T) {
+	sdkKey := "dvc_server_layers"
+	err := SetConfig(layerConfig(t, []string{"experiment-a", "experiment-b", "experiment-c", "outside"}, []*Layer{{
+		Id: "layer",
+		Allocations: []*LayerAllocation{
+			{Feature: "experiment-a", Start: 0, End: 0.25},
+			{Feature: "experiment-b", Start: 0.25, End: 0.5},
+			{Feature: "experiment-c", Start: 0.5, End: 0.75},
+		},
+	}}), sdkKey, "", "", "")
+	require.NoError(t, err)
+	config, err := getConfig(sdkKey)
+	require.NoError(t, err)
+
+	const users = 2000
+	assigned := make(map[string]int)
+	for i := 0; i < users; i++ {
+		user := api.User{UserId: fmt.Sprintf("user-%d", i)}.GetPopulatedUser(&api.PlatformData{})
+		bucketedConfig, err := GenerateBucketedConfig(sdkKey, user, nil)
+		require.NoError(t, err)
+		require.Contains(t, bucketedConfig.Features, "outside", "features outside the layer are unaffected")
+
+		var layerFeatures []string
+		for _, key := range []string{"experiment-a", "experiment-b", "experiment-c"} {
+			if _, ok := bucketedConfig.Features[key]; ok {
+				layerFeatures = append(layerFeatures, key)
+			}
+		}
+		require.LessOrEqual(t, len(layerFeatures), 1, "user %s is in more than one layer experiment", user.UserId)
+		if len(layerFeatures) == 0 {
+			assigned["none"]++
+			_, _, err := doesUserQualifyForFeature(config, config.GetFeatureForId("experiment-a"), newEvaluationContext(user, nil, time.Now()))
+			require.ErrorIs(t, err, ErrUserNotInLayerAllocation)
+			require.Equal(t, api.DefaultReasonUserNotInLayerAllocation, BucketResultErrorToDefaultReason(err))
+			continue
+		}
+		assigned[layerFeatures[0]]++
+	}
+
+	for _, key := range []string{"experiment-a", "experiment-b", "experiment-c", "none"} {
+		require.InDelta(t, 0.25, float64(assigned[key])/users, 0.05, "share of users assigned to %s", key)
+	}
+}
@@ -0,0 +1,361 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type LintSeverity string
+
+const (
+	// LintSeverityError is a problem that stops the config from loading or evaluating as intended
+	LintSeverityError LintSeverity = "error"
+	// LintSeverityWarning is a problem that is likely a mistake, but doesn't affect evaluation
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+// LintProblem is a problem found in a config by Lint.
+type LintProblem struct {
+	Severity LintSeverity `json:"severity"`
+	// Path locates the problem in the config, e.g. features[my-feature].targets[target-id]
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p LintProblem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Path, p.Message)
+}
+
+// distributionTolerance allows for floating point error in distribution percentages
+const distributionTolerance = 1e-9
+
+var versionFilterComparators = map[string]bool{
+	ComparatorGreater:      true,
+	ComparatorGreaterEqual: true,
+	ComparatorLess:         true,
+	ComparatorLessEqual:    true,
+}
+
+var knownFilterTypes = map[string]bool{
+	TypeAll:           true,
+	TypeUser:          true,
+	TypeOptIn:         true,
+	TypeAudienceMatch: true,
+	TypePrerequisite:  true,
+}
+
+// Lint checks the semantics of a raw config beyond what's needed to load it, and returns the
+// problems it finds ordered by path. An error is only returned if the config isn't valid JSON.
+// Unknown filters are reported as evaluated under the default UnknownFilterFailClosed policy.
+func Lint(rawConfig []byte) ([]LintProblem, error) {
+	return LintWithPolicy(rawConfig, UnknownFilterFailClosed)
+}
+
+// LintWithPolicy is Lint for a config loaded with the given unknown filter policy.
+func LintWithPolicy(rawConfig []byte, policy UnknownFilterPolicy) ([]LintProblem, error) {
+	var raw lintRawConfig
+	if err := json.Unmarshal(rawConfig, &raw); err != nil {
+		return nil, err
+	}
+	l := &linter{unknownFilterPolicy: policy}
+	l.lintRawFilters(&raw)
+
+	var config configBody
+	if err := json.Unmarshal(rawConfig, &config); err != nil {
+		l.report(LintSeverityError, "", "config can't be parsed: %s", err)
+		return l.sorted(), nil
+	}
+	if _, err := newConfigWithOptions(rawConfig, "", "", "", configOptions{unknownFilterPolicy: policy}); err != nil {
+		l.report(LintSeverityError, "", "config can't be loaded: %s", err)
+	}
+	l.lintFeatures(&config)
+	return l.sorted(), nil
+}
+
+type linter struct {
+	unknownFilterPolicy UnknownFilterPolicy
+	problems            []LintProblem
+}
+
+func (l *linter) report(severity LintSeverity, path, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) sorted() []LintProblem {
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		return l.problems[i].Path < l.problems[j].Path
+	})
+	return l.problems
+}
+
+// lintRawConfig holds the filters of a config as JSON, since filters with unknown types are
+// dropped when a config is parsed.
+type lintRawConfig struct {
+	Audiences map[string]struct {
+		Filters json.RawMessage `json:"filters"`
+	} `json:"audiences"`
+	Features []struct {
+		Key           string `json:"key"`
+		Configuration struct {
+			Targets []struct {
+				Id       string `json:"_id"`
+				Audience struct {
+					Filters json.RawMessage `json:"filters"`
+				} `json:"_audience"`
+			} `json:"targets"`
+		} `json:"configuration"`
+	} `json:"features"`
+}
+
+type lintRawFilter struct {
+	Type        string            `json:"type"`
+	SubType     string            `json:"subType"`
+	Comparator  string            `json:"comparator"`
+	DataKeyType string            `json:"dataKeyType"`
+	Operator    string            `json:"operator"`
+	Values      []interface{}     `json:"values"`
+	Filters     []json.RawMessage `json:"filters"`
+}
+
+func (l *linter) lintRawFilters(raw *lintRawConfig) {
+	for id, audience := range raw.Audiences {
+		l.lintRawFilter(fmt.Sprintf("audiences[%s]", id), audience.Filters)
+	}
+	for _, feature := range raw.Features {
+		for _, target := range feature.Configuration.Targets {
+			l.lintRawFilter(fmt.Sprintf("features[%s].targets[%s]", feature.Key, target.Id), target.Audience.Filters)
+		}
+	}
+}
+
+func (l *linter) lintRawFilter(path string, data json.RawMessage) {
+	if len(data) == 0 {
+		return
+	}
+	var f lintRawFilter
+	if err := json.Unmarshal(data, &f); err != nil {
+		return
+	}
+	if f.Operator != "" {
+		l.lintPattern(LintSeverityError, path, filter{}, "Operator", f.Operator)
+		for _, nested := range f.Filters {
+			l.lintRawFilter(path, nested)
+		}
+		return
+	}
+	if !knownFilterTypes[f.Type] {
+		l.report(LintSeverityError, path, "unknown filter type %q %s", f.Type, unknownFilterOutcome[l.unknownFilterPolicy])
+		return
+	}
+	l.lintPattern(LintSeverityError, path, filter{}, "SubType", f.SubType)
+	l.lintPattern(LintSeverityError, path, filter{}, "Comparator", f.Comparator)
+	l.lintPattern(LintSeverityError, path, CustomDataFilter{}, "DataKeyType", f.DataKeyType)
+	if (f.SubType == SubTypeAppVersion || f.SubType == SubTypePlatformVersion) && versionFilterComparators[f.Comparator] {
+		for _, value := range f.Values {
+			if version, ok := value.(string); !ok || !isComparableVersion(version) {
+				l.report(LintSeverityWarning, path, "%s filter value %v is not a comparable version", f.SubType, value)
+			}
+		}
+	}
+}
+
+// unknownFilterOutcome describes how an unknown filter is evaluated under each policy.
+var unknownFilterOutcome = map[UnknownFilterPolicy]string{
+	UnknownFilterFailClosed: "never passes",
+	UnknownFilterFailOpen:   "always passes",
+	UnknownFilterReject:     "rejects the config",
+}
+
+// lintPattern checks a config value against the regexp in the validate tag of the model's field,
+// since validate.Struct doesn't check them. Empty values are left to the other checks.
+func (l *linter) lintPattern(severity LintSeverity, path string, model interface{}, field, value string) {
+	if value == "" {
+		return
+	}
+	name, pattern, err := fieldPattern(model, field)
+	if err != nil {
+		l.report(LintSeverityError, path, "%s", err)
+		return
+	}
+	if pattern != nil && !pattern.MatchString(value) {
+		l.report(severity, path, "%s %q doesn't match %s", name, value, pattern)
+	}
+}
+
+// fieldPattern returns the JSON name of the model's field, and the regexp in its validate tag if
+// it has one.
+func fieldPattern(model interface{}, field string) (string, *regexp.Regexp, error) {
+	structField, ok := reflect.TypeOf(model).FieldByName(field)
+	if !ok {
+		return field, nil, nil
+	}
+	name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+	expr, ok := strings.CutPrefix(structField.Tag.Get("validate"), "regexp=")
+	if !ok {
+		return name, nil, nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return name, nil, fmt.Errorf("%s pattern %q is not a valid regex: %w", name, expr, err)
+	}
+	return name, pattern, nil
+}
+
+var (
+	versionInvalidCharacters = regexp.MustCompile(`[^(\d|.|\-)]`)
+	versionSuffix            = regexp.MustCompile(`-.*`)
+)
+
+// isComparableVersion reports whether a version filter value can be compared, after removing
+// the same characters checkVersionFilter does.
+func isComparableVersion(version string) bool {
+	version = versionSuffix.ReplaceAllString(versionInvalidCharacters.ReplaceAllString(version, ""), "")
+	return hasValidParts(false, strings.Split(version, "."))
+}
+
+func (l *linter) lintFeatures(config *configBody) {
+	variables := make(map[string]*Variable, len(config.Variables))
+	referencedVariables := make(map[string]bool)
+	for _, variable := range config.Variables {
+		variables[variable.Id] = variable
+	}
+
+	compiler := newFilterCompiler(config.Audiences)
+	for _, feature := range config.Features {
+		path := fmt.Sprintf("features[%s]", feature.Key)
+		l.lintPattern(LintSeverityWarning, path, ConfigFeature{}, "Type", feature.Type)
+		variations := make(map[string]bool, len(feature.Variations))
+		// featureVariables holds the ids of every variable set by a variation, in order
+		var featureVariables []string
+		seenVariables := make(map[string]bool)
+		for _, variation := range feature.Variations {
+			variations[variation.Id] = true
+			for _, variationVariable := range variation.Variables {
+				if !seenVariables[variationVariable.Var] {
+					seenVariables[variationVariable.Var] = true
+					featureVariables = append(featureVariables, variationVariable.Var)
+				}
+			}
+		}
+
+		for _, variation := range feature.Variations {
+			variationPath := fmt.Sprintf("%s.variations[%s]", path, variation.Key)
+			for _, variationVariable := range variation.Variables {
+				referencedVariables[variationVariable.Var] = true
+				variable, ok := variables[variationVariable.Var]
+				if !ok {
+					l.report(LintSeverityError, variationPath, "references missing variable %s", variationVariable.Var)
+					continue
+				}
+				if !variableValueMatchesType(variationVariable.Value, variable.Type) {
+					l.report(LintSeverityError, variationPath, "value %v of variable %s is not of type %s", variationVariable.Value, variable.Key, variable.Type)
+				}
+			}
+			for _, variableId := range featureVariables {
+				if variation.GetVariableById(variableId) == nil {
+					l.report(LintSeverityWarning, variationPath, "is missing variable %s", variableKey(variables, variableId))
+				}
+			}
+		}
+
+		if holdout := config.Project.Settings.Holdout; holdoutIncludes(holdout, feature) && holdoutTarget(holdout, feature) == nil {
+			l.report(LintSeverityWarning, path, "has no %s variation for the project holdout, held out users are defaulted", controlVariationKey(holdout))
+		}
+
+		var shadowedBy string
+		for _, target := range feature.Configuration.Targets {
+			targetPath := fmt.Sprintf("%s.targets[%s]", path, target.Id)
+			if shadowedBy != "" {
+				l.report(LintSeverityWarning, targetPath, "is unreachable, every user matches earlier target %s", shadowedBy)
+			}
+			l.lintDistribution(targetPath, target, variations)
+			if target.Rollout != nil {
+				l.lintRollout(targetPath, target.Rollout)
+			}
+			if shadowedBy == "" && target.Rollout == nil && target.ActiveWindow == nil && target.Audience != nil && matchesEveryone(compiler, target.Audience.Filters) {
+				shadowedBy = target.Id
+			}
+		}
+	}
+
+	for _, variable := range config.Variables {
+		if !referencedVariables[variable.Id] {
+			l.report(LintSeverityWarning, fmt.Sprintf("variables[%s]", variable.Key), "is not used by any feature")
+		}
+	}
+}
+
+func (l *linter) lintDistribution(path string, target *Target, variations map[string]bool) {
+	total := 0.0
+	for _, distribution := range target.Distribution {
+		total += distribution.Percentage
+		if !variations[distribution.Variation] {
+			l.report(LintSeverityError, path, "distributes to missing variation %s", distribution.Variation)
+		}
+	}
+	if math.Abs(total-1) > distributionTolerance {
+		l.report(LintSeverityError, path, "distribution percentages sum to %g, not 1", total)
+	}
+}
+
+func (l *linter) lintRollout(path string, rollout *Rollout) {
+	l.lintPattern(LintSeverityError, path, Rollout{}, "Type", rollout.Type)
+	for i, stage := range rollout.Stages {
+		l.lintPattern(LintSeverityError, path, RolloutStage{}, "Type", stage.Type)
+		if stage.Date.Before(rollout.StartDate) {
+			l.report(LintSeverityWarning, path, "rollout stage %d is before the rollout starts", i)
+		}
+		if i > 0 && stage.Date.Before(rollout.Stages[i-1].Date) {
+			l.report(LintSeverityError, path, "rollout stage %d is before the stage preceding it", i)
+		}
+	}
+}
+
+// matchesEveryone reports whether the audience filters fold to a constant pass when compiled.
+func matchesEveryone(compiler *filterCompiler, operator *AudienceOperator) bool {
+	compiled := compiler.compile(operator)
+	return compiled.isConstant && compiled.evaluate(nil)
+}
+
+// variableValueMatchesType reports whether a variation value is of the variable's type. JSON
+// values may be objects or arrays, or encoded as a string.
+func variableValueMatchesType(value interface{}, variableType string) bool {
+	switch value := value.(type) {
+	case bool:
+		return variableType == VariableTypesBool
+	case float64:
+		return variableType == VariableTypesNumber
+	case string:
+		return variableType == VariableTypesString || (variableType == VariableTypesJSON && isJSONObjectOrArray(value))
+	case map[string]interface{}, []interface{}:
+		return variableType == VariableTypesJSON
+	}
+	return false
+}
+
+func variableKey(variables map[string]*Variable, id string) string {
+	if variable, ok := variables[id]; ok {
+		return variable.Key
+	}
+	return id
+}
+
+func isJSONObjectOrArray(value string) bool {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return false
+	}
+	switch decoded.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
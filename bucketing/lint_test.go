@@ -0,0 +1,222 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mutate   func(feature, target map[string]interface{}, config map[string]interface{})
+		expected []LintProblem
+	}{
+		{
+			name:   "valid config",
+			mutate: func(feature, target, config map[string]interface{}) {},
+		},
+		{
+			name: "distribution doesn't sum to 1",
+			mutate: func(feature, target, config map[string]interface{}) {
+				target["distribution"] = []interface{}{map[string]interface{}{"_variation": "on", "percentage": 0.5}}
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].targets[target-a]", "distribution percentages sum to 0.5, not 1"}},
+		},
+		{
+			name: "distribution to missing variation",
+			mutate: func(feature, target, config map[string]interface{}) {
+				target["distribution"] = []interface{}{map[string]interface{}{"_variation": "missing", "percentage": 1}}
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].targets[target-a]", "distributes to missing variation missing"}},
+		},
+		{
+			name: "variation missing variable",
+			mutate: func(feature, target, config map[string]interface{}) {
+				variationAt(feature, 1)["variables"] = []interface{}{}
+			},
+			expected: []LintProblem{{LintSeverityWarning, "features[a].variations[off]", "is missing variable var-a"}},
+		},
+		{
+			name: "variation value doesn't match variable type",
+			mutate: func(feature, target, config map[string]interface{}) {
+				variationAt(feature, 0)["variables"] = []interface{}{map[string]interface{}{"_var": "var-a", "value": "yes"}}
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].variations[on]", "value yes of variable var-a is not of type Boolean"}},
+		},
+		{
+			name: "unused variable",
+			mutate: func(feature, target, config map[string]interface{}) {
+				config["variables"] = append(config["variables"].([]interface{}), map[string]interface{}{"_id": "unused", "key": "unused", "type": "String"})
+			},
+			expected: []LintProblem{{LintSeverityWarning, "variables[unused]", "is not used by any feature"}},
+		},
+		{
+			name: "target shadowed by an all target",
+			mutate: func(feature, target, config map[string]interface{}) {
+				configuration := feature["configuration"].(map[string]interface{})
+				shadowed := map[string]interface{}{"_id": "shadowed", "_audience": target["_audience"], "distribution": target["distribution"]}
+				configuration["targets"] = append(configuration["targets"].([]interface{}), shadowed)
+			},
+			expected: []LintProblem{{LintSeverityWarning, "features[a].targets[shadowed]", "is unreachable, every user matches earlier target target-a"}},
+		},
+		{
+			name: "target shadowed by a nested all target",
+			mutate: func(feature, target, config map[string]interface{}) {
+				setTargetFilter(target, map[string]interface{}{"operator": "or", "filters": []interface{}{
+					map[string]interface{}{"operator": "and", "filters": []interface{}{map[string]interface{}{"type": "all"}}},
+					map[string]interface{}{"type": "user", "subType": "user_id", "comparator": "=", "values": []interface{}{"user"}},
+				}})
+				configuration := feature["configuration"].(map[string]interface{})
+				shadowed := map[string]interface{}{"_id": "shadowed", "_audience": target["_audience"], "distribution": target["distribution"]}
+				configuration["targets"] = append(configuration["targets"].([]interface{}), shadowed)
+			},
+			expected: []LintProblem{{LintSeverityWarning, "features[a].targets[shadowed]", "is unreachable, every user matches earlier target target-a"}},
+		},
+		{
+			name: "target shadowed by an audience matching every user",
+			mutate: func(feature, target, config map[string]interface{}) {
+				config["audiences"] = map[string]interface{}{
+					"everyone": map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{map[string]interface{}{"type": "all"}}}},
+				}
+				setTargetFilter(target, map[string]interface{}{"type": "audienceMatch", "comparator": "=", "_audiences": []interface{}{"everyone"}})
+				configuration := feature["configuration"].(map[string]interface{})
+				shadowed := map[string]interface{}{"_id": "shadowed", "_audience": target["_audience"], "distribution": target["distribution"]}
+				configuration["targets"] = append(configuration["targets"].([]interface{}), shadowed)
+			},
+			expected: []LintProblem{{LintSeverityWarning, "features[a].targets[shadowed]", "is unreachable, every user matches earlier target target-a"}},
+		},
+		{
+			name: "rollout stages out of order",
+			mutate: func(feature, target, config map[string]interface{}) {
+				target["rollout"] = map[string]interface{}{
+					"type":      "stepped",
+					"startDate": "2024-01-01T00:00:00Z",
+					"stages": []interface{}{
+						map[string]interface{}{"type": "discrete", "date": "2024-01-03T00:00:00Z", "percentage": 0.5},
+						map[string]interface{}{"type": "discrete", "date": "2024-01-02T00:00:00Z", "percentage": 1},
+					},
+				}
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].targets[target-a]", "rollout stage 1 is before the stage preceding it"}},
+		},
+		{
+			name: "holdout feature without control variation",
+			mutate: func(feature, target, config map[string]interface{}) {
+				feature["type"] = "experiment"
+				config["project"].(map[string]interface{})["settings"] = map[string]interface{}{"holdout": map[string]interface{}{"percentage": 0.1, "controlVariation": "baseline"}}
+			},
+			expected: []LintProblem{{LintSeverityWarning, "features[a]", "has no baseline variation for the project holdout, held out users are defaulted"}},
+		},
+		{
+			name: "unknown filter type",
+			mutate: func(feature, target, config map[string]interface{}) {
+				setTargetFilter(target, map[string]interface{}{"type": "somethingNew"})
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].targets[target-a]", `unknown filter type "somethingNew" never passes`}},
+		},
+		{
+			name: "filter value doesn't match its pattern",
+			mutate: func(feature, target, config map[string]interface{}) {
+				setTargetFilter(target, map[string]interface{}{"type": "user", "subType": "email", "comparator": "matches", "values": []interface{}{"a"}})
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].targets[target-a]", `comparator "matches" doesn't match ^(=|!=|>|>=|<|<=|exist|!exist|contain|!contain|startWith|!startWith|endWith|!endWith|before|after|between|withinLastDays|withinNextDays|containsAll|containsAny|size)$`}},
+		},
+		{
+			name: "rollout type doesn't match its pattern",
+			mutate: func(feature, target, config map[string]interface{}) {
+				target["rollout"] = map[string]interface{}{"type": "instant", "startDate": "2024-01-01T00:00:00Z"}
+			},
+			expected: []LintProblem{{LintSeverityError, "features[a].targets[target-a]", `type "instant" doesn't match ^(schedule|gradual|stepped)$`}},
+		},
+		{
+			name: "invalid version",
+			mutate: func(feature, target, config map[string]interface{}) {
+				setTargetFilter(target, map[string]interface{}{"type": "user", "subType": "appVersion", "comparator": ">", "values": []interface{}{"1.2.3", "latest"}})
+			},
+			expected: []LintProblem{{LintSeverityWarning, "features[a].targets[target-a]", "appVersion filter value latest is not a comparable version"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var config map[string]interface{}
+			require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "a", variation: "on"}}), &config))
+			feature := config["features"].([]interface{})[0].(map[string]interface{})
+			target := feature["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+			tc.mutate(feature, target, config)
+			rawConfig, err := json.Marshal(config)
+			require.NoError(t, err)
+
+			problems, err := Lint(rawConfig)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, problems)
+		})
+	}
+}
+
+func TestLintWithPolicy(t *testing.T) {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "a", variation: "on"}}), &config))
+	feature := config["features"].([]interface{})[0].(map[string]interface{})
+	setTargetFilter(feature["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{}), map[string]interface{}{"type": "somethingNew"})
+	rawConfig, err := json.Marshal(config)
+	require.NoError(t, err)
+
+	problems, err := LintWithPolicy(rawConfig, UnknownFilterFailOpen)
+	require.NoError(t, err)
+	require.Equal(t, []LintProblem{{LintSeverityError, "features[a].targets[target-a]", `unknown filter type "somethingNew" always passes`}}, problems)
+
+	problems, err = LintWithPolicy(rawConfig, UnknownFilterReject)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	require.Contains(t, problems[0].Message, "config can't be loaded")
+	require.Equal(t, `unknown filter type "somethingNew" rejects the config`, problems[1].Message)
+}
+
+func TestFieldPattern(t *testing.T) {
+	type model struct {
+		Valid   string `json:"valid,omitempty" validate:"regexp=^(a|b)$"`
+		Invalid string `json:"invalid" validate:"regexp=^(a|b$"`
+		Plain   string `json:"plain"`
+	}
+
+	name, pattern, err := fieldPattern(model{}, "Valid")
+	require.NoError(t, err)
+	require.Equal(t, "valid", name)
+	require.True(t, pattern.MatchString("a"))
+	require.False(t, pattern.MatchString("c"))
+
+	name, pattern, err = fieldPattern(model{}, "Plain")
+	require.NoError(t, err)
+	require.Equal(t, "plain", name)
+	require.Nil(t, pattern)
+
+	_, _, err = fieldPattern(model{}, "Invalid")
+	require.ErrorContains(t, err, `invalid pattern "^(a|b$" is not a valid regex`)
+
+	l := &linter{}
+	l.lintPattern(LintSeverityWarning, "path", model{}, "Invalid", "a")
+	require.Len(t, l.problems, 1)
+	require.Equal(t, LintSeverityError, l.problems[0].Severity)
+}
+
+func TestLint_UnloadableConfig(t *testing.T) {
+	problems, err := Lint([]byte(`{"features": [{"key": "a", "type": "not-a-type"}]}`))
+	require.NoError(t, err)
+	require.NotEmpty(t, problems)
+	require.Equal(t, LintSeverityError, problems[0].Severity)
+	require.Contains(t, problems[0].Message, "config can't be loaded")
+
+	_, err = Lint([]byte("not json"))
+	require.Error(t, err)
+}
+
+func variationAt(feature map[string]interface{}, index int) map[string]interface{} {
+	return feature["variations"].([]interface{})[index].(map[string]interface{})
+}
+
+func setTargetFilter(target map[string]interface{}, filter map[string]interface{}) {
+	target["_audience"] = map[string]interface{}{"_id": "audience", "filters": map[string]interface{}{"operator": "and", "filters": []interface{}{filter}}}
+}
@@ -2,6 +2,7 @@ package bucketing
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -30,6 +31,7 @@ type configBody struct {
 	Environment            api.Environment         `json:"environment" validate:"required"`
 	Features               []*ConfigFeature        `json:"features" validate:"required"`
 	Variables              []*Variable             `json:"variables" validate:"required,dive"`
+	Layers                 []*Layer                `json:"layers,omitempty" validate:"dive,required"`
 	etag                   string
 	rayId                  string
 	lastModified           string
@@ -37,11 +39,32 @@ type configBody struct {
 	variableIdMap          map[string]*Variable
 	variableKeyMap         map[string]*Variable
 	variableIdToFeatureMap map[string]*ConfigFeature
+	featureIdMap           map[string]*ConfigFeature
+	featureLayers          map[string]featureLayer
+	compiledTargets        map[*Target]compiledFilter
+	unknownFilters         []UnknownFilterOccurrence
+	// unknownFilterTargets are never matched, as they depend on an unknown filter
+	unknownFilterTargets map[*Target]bool
+	quarantinedFeatures  []QuarantinedFeature
+	// quarantinedVariables maps the ids of the variables of quarantined features to their feature key
+	quarantinedVariables map[string]string
+	// featurePrerequisites holds the prerequisite filters of each feature's targets, by feature id
+	featurePrerequisites map[string][]*PrerequisiteFilter
+	// bucketingKeyCounts counts how often targets bucketing by custom data fell back from their key
+	bucketingKeyCounts map[*Target]*bucketingKeyCounts
 }
 
 func newConfig(configJSON []byte, etag, rayId, lastModified string) (*configBody, error) {
+	return newConfigWithOptions(configJSON, etag, rayId, lastModified, configOptions{})
+}
+
+func newConfigWithOptions(configJSON []byte, etag, rayId, lastModified string, options configOptions) (*configBody, error) {
 	config := configBody{}
-	if err := json.Unmarshal(configJSON, &config); err != nil {
+	if options.quarantineInvalidFeatures {
+		if err := config.unmarshalQuarantiningFeatures(configJSON); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(configJSON, &config); err != nil {
 		return nil, err
 	}
 	if err := validate.Struct(config); err != nil {
@@ -50,6 +73,30 @@ func newConfig(configJSON []byte, etag, rayId, lastModified string) (*configBody
 	if config.Audiences == nil {
 		config.Audiences = make(map[string]NoIdAudience)
 	}
+	validators := []func(config *configBody) error{
+		validateAudienceReferences,
+		validateFeaturePrerequisites,
+		validateLayers,
+		validateSchedules,
+		func(config *configBody) error {
+			return applyUnknownFilterPolicy(config, options.unknownFilterPolicy)
+		},
+	}
+	for i := 0; i < len(validators); i++ {
+		err := validators[i](&config)
+		if err == nil {
+			continue
+		}
+		var featureErr featureError
+		if !options.quarantineInvalidFeatures || !errors.As(err, &featureErr) {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+		if config.quarantineFeatures(featureErr.Features(), err.Error()) == 0 {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+		// Quarantining features can invalidate others, e.g. through prerequisites, so start over
+		i = -1
+	}
 	config.compile(etag, rayId, lastModified)
 	return &config, nil
 }
@@ -75,10 +122,19 @@ func (c *configBody) GetFeatureForVariableId(id string) *ConfigFeature {
 	return nil
 }
 
+func (c *configBody) GetFeatureForId(id string) *ConfigFeature {
+	if feature, ok := c.featureIdMap[id]; ok {
+		return feature
+	}
+	return nil
+}
+
 func (c *configBody) compile(etag, rayId, lastModified string) {
 	// Build mappings of IDs and keys to features and variables.
 	variableIdToFeatureMap := make(map[string]*ConfigFeature)
+	featureIdMap := make(map[string]*ConfigFeature, len(c.Features))
 	for _, feature := range c.Features {
+		featureIdMap[feature.Id] = feature
 		for _, v := range feature.Variations {
 			for _, vv := range v.Variables {
 				if _, ok := variableIdToFeatureMap[vv.Var]; !ok {
@@ -96,14 +152,49 @@ func (c *configBody) compile(etag, rayId, lastModified string) {
 	}
 
 	c.variableIdToFeatureMap = variableIdToFeatureMap
+	c.featureIdMap = featureIdMap
+	c.featureLayers = make(map[string]featureLayer)
+	for _, layer := range c.Layers {
+		for _, allocation := range layer.Allocations {
+			c.featureLayers[allocation.Feature] = featureLayer{layer: layer, allocation: allocation}
+		}
+	}
 	c.variableIdMap = variableIdMap
 	c.variableKeyMap = variableKeyMap
 	c.etag = etag
 	c.rayId = rayId
 	c.lastModified = lastModified
+	// Compile each target's audience, with referenced audiences inlined
+	compiler := newFilterCompiler(c.Audiences)
+	compiler.config = c
+	c.compiledTargets = make(map[*Target]compiledFilter)
+	c.featurePrerequisites = nil
+	for _, feature := range c.Features {
+		if prerequisites := featurePrerequisiteFilters(c, feature); len(prerequisites) > 0 {
+			if c.featurePrerequisites == nil {
+				c.featurePrerequisites = make(map[string][]*PrerequisiteFilter)
+			}
+			c.featurePrerequisites[feature.Id] = prerequisites
+		}
+		for _, target := range feature.Configuration.Targets {
+			if c.unknownFilterTargets[target] {
+				c.compiledTargets[target] = compiledFail
+			} else if target.Audience != nil {
+				c.compiledTargets[target] = compiler.compile(target.Audience.Filters)
+			}
+		}
+	}
 	// Sort the feature distributions by "_variation" attribute in descending alphabetical order
+	c.bucketingKeyCounts = nil
 	for _, feature := range c.Features {
 		for _, target := range feature.Configuration.Targets {
+			target.compileBucketingKeys()
+			if target.bucketingKeyPaths != nil {
+				if c.bucketingKeyCounts == nil {
+					c.bucketingKeyCounts = make(map[*Target]*bucketingKeyCounts)
+				}
+				c.bucketingKeyCounts[target] = &bucketingKeyCounts{feature: feature}
+			}
 			sort.Slice(target.Distribution, func(i, j int) bool {
 				return target.Distribution[i].Variation > target.Distribution[j].Variation
 			})
@@ -112,5 +203,18 @@ func (c *configBody) compile(etag, rayId, lastModified string) {
 }
 
 func (c *configBody) Equals(c2 configBody) bool {
-	return reflect.DeepEqual(*c, c2)
+	c1 := *c
+	// Compiled filters are closures, which are never deeply equal
+	c1.compiledTargets, c2.compiledTargets = nil, nil
+	c1.bucketingKeyCounts, c2.bucketingKeyCounts = nil, nil
+	return reflect.DeepEqual(c1, c2)
+}
+
+// targetMatchesAudience evaluates a target's compiled audience, falling back to evaluating the
+// filter tree directly for targets that weren't part of the compiled config.
+func (c *configBody) targetMatchesAudience(target *Target, ctx *evaluationContext) bool {
+	if compiled, ok := c.compiledTargets[target]; ok {
+		return compiled.evaluate(ctx)
+	}
+	return evaluateFilter(target.Audience.Filters, c, ctx)
 }
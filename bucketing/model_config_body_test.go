@@ -66,6 +66,9 @@ func TestParseConfigBody(t *testing.T) {
 				variableIdMap:          map[string]*Variable{},
 				variableKeyMap:         map[string]*Variable{},
 				variableIdToFeatureMap: map[string]*ConfigFeature{},
+				featureIdMap:           map[string]*ConfigFeature{},
+				featureLayers:          map[string]featureLayer{},
+				compiledTargets:        map[*Target]compiledFilter{},
 			},
 			expectError: false,
 		},
@@ -101,6 +104,9 @@ func TestParseConfigBody(t *testing.T) {
 				variableIdMap:          map[string]*Variable{},
 				variableKeyMap:         map[string]*Variable{},
 				variableIdToFeatureMap: map[string]*ConfigFeature{},
+				featureIdMap:           map[string]*ConfigFeature{},
+				featureLayers:          map[string]featureLayer{},
+				compiledTargets:        map[*Target]compiledFilter{},
 			},
 			expectError: false,
 		},
@@ -3,6 +3,9 @@ package bucketing
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/devcyclehq/go-server-sdk/v2/api"
 	"github.com/devcyclehq/go-server-sdk/v2/util"
@@ -20,9 +23,9 @@ type InitializedFilter interface {
 
 // Represents a partially parsed filter object from the JSON, before parsing a specific filter type
 type filter struct {
-	Type       string `json:"type" validate:"regexp=^(all|user|optIn)$"`
-	SubType    string `json:"subType" validate:"regexp=^(|user_id|email|ip|country|platform|platformVersion|appVersion|deviceModel|customData)$"`
-	Comparator string `json:"comparator" validate:"regexp=^(=|!=|>|>=|<|<=|exist|!exist|contain|!contain)$"`
+	Type       string `json:"type" validate:"regexp=^(all|user|optIn|audienceMatch|prerequisite)$"`
+	SubType    string `json:"subType" validate:"regexp=^(|user_id|email|ip|country|platform|platformVersion|appVersion|deviceModel|customData|language|name)$"`
+	Comparator string `json:"comparator" validate:"regexp=^(=|!=|>|>=|<|<=|exist|!exist|contain|!contain|startWith|!startWith|endWith|!endWith|before|after|between|withinLastDays|withinNextDays|containsAll|containsAny|size)$"`
 	Operator   string `json:"operator" validate:"regexp=^(and|or)$"`
 }
 
@@ -86,8 +89,11 @@ func (m *MixedFilters) UnmarshalJSON(data []byte) error {
 			}
 		case TypeAudienceMatch:
 			filter = &AudienceMatchFilter{}
+		case TypePrerequisite:
+			filter = &PrerequisiteFilter{}
 		default:
 			util.Warnf(`Warning: Invalid filter type %s. To leverage this new filter definition, please update to the latest version of the DevCycle SDK.`, partial.Type)
+			filters[index] = &UnknownFilter{filter: partial}
 			continue
 		}
 
@@ -134,10 +140,14 @@ type OptInFilter struct {
 type UserFilter struct {
 	filter
 	Values []interface{} `json:"values"`
+	// CaseInsensitive compares string values using Unicode case folding
+	CaseInsensitive bool `json:"caseInsensitive"`
 
 	CompiledStringVals []string
 	CompiledBoolVals   []bool
 	CompiledNumVals    []float64
+	CompiledIPPrefixes []netip.Prefix
+	CompiledDateVals   []time.Time
 }
 
 func (filter *UserFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
@@ -149,7 +159,57 @@ func (f UserFilter) Type() string {
 }
 
 func (f *UserFilter) Initialize() error {
-	return f.compileValues()
+	if err := f.compileValues(); err != nil {
+		return err
+	}
+	if f.CaseInsensitive {
+		for i, value := range f.CompiledStringVals {
+			f.CompiledStringVals[i] = foldString(value)
+		}
+	}
+	switch f.SubType {
+	case SubTypeIP:
+		return f.compileIPPrefixes()
+	case SubTypeLanguage:
+		for i, value := range f.CompiledStringVals {
+			f.CompiledStringVals[i] = normalizeLanguageTag(value)
+		}
+	}
+	return nil
+}
+
+// compileIPPrefixes parses the string values of an IP filter into prefixes. A value
+// without a prefix length (e.g. "10.0.0.1") is treated as a single-address prefix.
+func (u *UserFilter) compileIPPrefixes() error {
+	prefixes := make([]netip.Prefix, 0, len(u.CompiledStringVals))
+	for _, value := range u.CompiledStringVals {
+		prefix, err := parseIPPrefix(value)
+		if err != nil {
+			return fmt.Errorf("invalid IP filter value %q: %w", value, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	u.CompiledIPPrefixes = prefixes
+	return nil
+}
+
+func parseIPPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
 }
 
 func (u *UserFilter) compileValues() error {
@@ -199,11 +259,64 @@ func (u *UserFilter) compileValues() error {
 type CustomDataFilter struct {
 	*UserFilter
 	DataKey     string `json:"dataKey"`
-	DataKeyType string `json:"dataKeyType" validate:"regexp=^(String|Boolean|Number)$"`
+	DataKeyType string `json:"dataKeyType" validate:"regexp=^(String|Boolean|Number|Date)$"`
+
+	CompiledDataKeyPath []string
+}
+
+func (f *CustomDataFilter) Initialize() error {
+	if err := f.UserFilter.Initialize(); err != nil {
+		return err
+	}
+	f.CompiledDataKeyPath = parseDataKeyPath(f.DataKey)
+	if f.DataKeyType == DataKeyTypeDate {
+		return f.compileDateValues()
+	}
+	return nil
+}
+
+// compileDateValues parses the values of a Date filter into times. Absolute comparators take
+// RFC3339 strings or epoch milliseconds, relative comparators take a number of days.
+func (f *CustomDataFilter) compileDateValues() error {
+	switch f.GetComparator() {
+	case ComparatorWithinLast, ComparatorWithinNext:
+		if len(f.CompiledNumVals) != len(f.Values) {
+			return fmt.Errorf("%s filter values must be a number of days", f.GetComparator())
+		}
+		for _, days := range f.CompiledNumVals {
+			if days < 0 {
+				return fmt.Errorf("%s filter values must not be negative. Got: %v", f.GetComparator(), days)
+			}
+		}
+		return nil
+	case ComparatorExist, ComparatorNotExist:
+		return nil
+	}
+
+	dates := make([]time.Time, 0, len(f.Values))
+	for _, value := range f.Values {
+		date, ok := parseDateValue(value)
+		if !ok {
+			return fmt.Errorf("date filter values must be RFC3339 strings or epoch milliseconds. Got: %T %#v", value, value)
+		}
+		dates = append(dates, date)
+	}
+	if f.GetComparator() == ComparatorBetween {
+		if len(dates) != 2 {
+			return fmt.Errorf("between filter requires exactly 2 values, got %d", len(dates))
+		}
+		if dates[1].Before(dates[0]) {
+			return fmt.Errorf("between filter start %s is after end %s", dates[0].Format(time.RFC3339), dates[1].Format(time.RFC3339))
+		}
+	}
+	f.CompiledDateVals = dates
+	return nil
 }
 
+// Evaluate evaluates relative date comparators against the current time. Bucketing evaluates
+// them at the evaluation time of its context instead, see evaluateFilter.
 func (filter *CustomDataFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
-	return checkCustomData(filter, user.CombinedCustomData(), clientCustomData)
+	return checkCustomData(filter, user.CombinedCustomData(), clientCustomData, time.Now())
 }
 
 func (f CustomDataFilter) Type() string {
@@ -1,9 +1,13 @@
 package bucketing
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
 )
 
 func TestCheckCustomData(t *testing.T) {
@@ -64,13 +68,13 @@ func TestCheckCustomData(t *testing.T) {
 
 		require.NoError(t, testFilter.Initialize())
 
-		result := checkCustomData(testFilter, test.data, nil)
+		result := checkCustomData(testFilter, test.data, nil, time.Now())
 		if result != test.expected {
 			t.Errorf("Test %s failed. Expected %t, got %t", test.name, test.expected, result)
 		}
 
 		// test again but use the data as clientCustomData instead to make sure it still works
-		result2 := checkCustomData(testFilter, nil, test.data)
+		result2 := checkCustomData(testFilter, nil, test.data, time.Now())
 		if result2 != test.expected {
 			t.Errorf("Test %s (clientCustomData variation) failed. Expected %t, got %t", test.name, test.expected, result)
 		}
@@ -503,3 +507,328 @@ func TestCheckVersionFilters(t *testing.T) {
 	}
 
 }
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func (c fixedClock) NewTicker(d time.Duration) api.Ticker {
+	return api.SystemClock{}.NewTicker(d)
+}
+
+func TestCheckCustomData_Date(t *testing.T) {
+	current := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	signup := "2024-03-01T00:00:00Z"
+	signupMillis := float64(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+
+	tests := []struct {
+		name       string
+		comparator string
+		values     []interface{}
+		expected   bool
+		data       map[string]interface{}
+	}{
+		{"before with RFC3339 value", ComparatorBefore, []interface{}{"2024-03-02T00:00:00Z"}, true, map[string]interface{}{"signup": signup}},
+		{"before with later date", ComparatorBefore, []interface{}{"2024-02-01T00:00:00Z"}, false, map[string]interface{}{"signup": signup}},
+		{"before with epoch millis filter value", ComparatorBefore, []interface{}{signupMillis + 1}, true, map[string]interface{}{"signup": signup}},
+		{"after with epoch millis data", ComparatorAfter, []interface{}{"2024-02-01T00:00:00Z"}, true, map[string]interface{}{"signup": signupMillis}},
+		{"after with int64 epoch millis data", ComparatorAfter, []interface{}{"2024-02-01T00:00:00Z"}, true, map[string]interface{}{"signup": int64(signupMillis)}},
+		{"after with int32 epoch millis data", ComparatorAfter, []interface{}{"1969-12-01T00:00:00Z"}, true, map[string]interface{}{"signup": int32(0)}},
+		{"after with uint64 epoch millis data", ComparatorAfter, []interface{}{"2024-02-01T00:00:00Z"}, true, map[string]interface{}{"signup": uint64(signupMillis)}},
+		{"after with json.Number epoch millis data", ComparatorAfter, []interface{}{"2024-02-01T00:00:00Z"}, true, map[string]interface{}{"signup": json.Number("1709251200000")}},
+		{"after with time.Time data", ComparatorAfter, []interface{}{"2024-02-01T00:00:00Z"}, true, map[string]interface{}{"signup": time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)}},
+		{"after with offset timezone", ComparatorAfter, []interface{}{"2024-02-29T23:00:00Z"}, true, map[string]interface{}{"signup": "2024-03-01T01:00:00+01:00"}},
+		{"not after", ComparatorAfter, []interface{}{"2024-03-01T00:00:00Z"}, false, map[string]interface{}{"signup": signup}},
+		{"between inclusive start", ComparatorBetween, []interface{}{"2024-03-01T00:00:00Z", "2024-04-01T00:00:00Z"}, true, map[string]interface{}{"signup": signup}},
+		{"between outside", ComparatorBetween, []interface{}{"2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z"}, false, map[string]interface{}{"signup": signup}},
+		{"equal", ComparatorEqual, []interface{}{"2024-03-01T00:00:00Z"}, true, map[string]interface{}{"signup": signupMillis}},
+		{"not equal", ComparatorNotEqual, []interface{}{"2024-03-01T00:00:00Z"}, false, map[string]interface{}{"signup": signupMillis}},
+		{"within last 30 days", ComparatorWithinLast, []interface{}{float64(30)}, true, map[string]interface{}{"signup": signup}},
+		{"not within last 7 days", ComparatorWithinLast, []interface{}{float64(7)}, false, map[string]interface{}{"signup": signup}},
+		{"future date is not within last days", ComparatorWithinLast, []interface{}{float64(30)}, false, map[string]interface{}{"signup": "2024-03-16T00:00:00Z"}},
+		{"within next 3 days", ComparatorWithinNext, []interface{}{float64(3)}, true, map[string]interface{}{"trialEnd": "2024-03-17T00:00:00Z"}},
+		{"not within next 1 day", ComparatorWithinNext, []interface{}{float64(1)}, false, map[string]interface{}{"trialEnd": "2024-03-17T00:00:00Z"}},
+		{"exists", ComparatorExist, []interface{}{}, true, map[string]interface{}{"signup": signup}},
+		{"unparseable value does not exist", ComparatorNotExist, []interface{}{}, true, map[string]interface{}{"signup": "last tuesday"}},
+		{"unparseable value never matches", ComparatorBefore, []interface{}{"2030-01-01T00:00:00Z"}, false, map[string]interface{}{"signup": "last tuesday"}},
+		{"missing value never matches", ComparatorAfter, []interface{}{"2020-01-01T00:00:00Z"}, false, map[string]interface{}{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dataKey := "signup"
+			if _, ok := test.data["trialEnd"]; ok {
+				dataKey = "trialEnd"
+			}
+			testFilter := &CustomDataFilter{
+				UserFilter: &UserFilter{
+					filter: filter{
+						Type:       "user",
+						SubType:    "customData",
+						Comparator: test.comparator,
+					},
+					Values: test.values,
+				},
+				DataKey:     dataKey,
+				DataKeyType: DataKeyTypeDate,
+			}
+			require.NoError(t, testFilter.Initialize())
+			require.Equal(t, test.expected, checkCustomData(testFilter, test.data, nil, current))
+		})
+	}
+}
+
+func TestEvaluateFilter_DateAtEvaluationTime(t *testing.T) {
+	signedUpRecently := &CustomDataFilter{
+		UserFilter: &UserFilter{
+			filter: filter{Type: TypeUser, SubType: SubTypeCustomData, Comparator: ComparatorWithinLast},
+			Values: []interface{}{float64(7)},
+		},
+		DataKey:     "signup",
+		DataKeyType: DataKeyTypeDate,
+	}
+	require.NoError(t, signedUpRecently.Initialize())
+	audience := &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{signedUpRecently}}
+	user := api.User{UserId: "user", CustomData: map[string]interface{}{"signup": "2024-03-01T00:00:00Z"}}.GetPopulatedUser(&api.PlatformData{})
+
+	signupWeek := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	require.True(t, evaluateFilter(audience, &configBody{}, newEvaluationContext(user, nil, signupWeek)))
+	require.True(t, newFilterCompiler(nil).compile(audience).evaluate(newEvaluationContext(user, nil, signupWeek)))
+	require.False(t, evaluateFilter(audience, &configBody{}, newEvaluationContext(user, nil, signupWeek.AddDate(0, 1, 0))))
+}
+
+func TestCustomDataFilter_InitializeInvalidDate(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator string
+		values     []interface{}
+	}{
+		{"unparseable date", ComparatorBefore, []interface{}{"yesterday"}},
+		{"between with one value", ComparatorBetween, []interface{}{"2024-03-01T00:00:00Z"}},
+		{"between with end before start", ComparatorBetween, []interface{}{"2024-03-01T00:00:00Z", "2024-01-01T00:00:00Z"}},
+		{"relative with string value", ComparatorWithinLast, []interface{}{"7"}},
+		{"relative with negative days", ComparatorWithinNext, []interface{}{float64(-1)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testFilter := &CustomDataFilter{
+				UserFilter: &UserFilter{
+					filter: filter{
+						Type:       "user",
+						SubType:    "customData",
+						Comparator: test.comparator,
+					},
+					Values: test.values,
+				},
+				DataKey:     "signup",
+				DataKeyType: DataKeyTypeDate,
+			}
+			require.Error(t, testFilter.Initialize())
+		})
+	}
+}
+
+func TestCheckCustomData_List(t *testing.T) {
+	tests := []struct {
+		name        string
+		comparator  string
+		values      []interface{}
+		dataKeyType string
+		expected    bool
+		data        interface{}
+	}{
+		{"= matches any element", ComparatorEqual, []interface{}{"pro"}, "String", true, []interface{}{"basic", "pro"}},
+		{"= matches []string", ComparatorEqual, []interface{}{"pro"}, "String", true, []string{"basic", "pro"}},
+		{"= no element matches", ComparatorEqual, []interface{}{"enterprise"}, "String", false, []string{"basic", "pro"}},
+		{"!= no element matches", ComparatorNotEqual, []interface{}{"enterprise"}, "String", true, []string{"basic", "pro"}},
+		{"!= an element matches", ComparatorNotEqual, []interface{}{"pro"}, "String", false, []string{"basic", "pro"}},
+		{"contain over elements", ComparatorContain, []interface{}{"team-"}, "String", true, []string{"org-1", "team-42"}},
+		{"!contain over elements", ComparatorNotContain, []interface{}{"team-"}, "String", false, []string{"org-1", "team-42"}},
+		{"startWith over elements", ComparatorStartWith, []interface{}{"org"}, "String", true, []string{"team-42", "org-1"}},
+		{"containsAll", ComparatorContainsAll, []interface{}{"a", "b"}, "String", true, []string{"c", "b", "a"}},
+		{"containsAll missing one", ComparatorContainsAll, []interface{}{"a", "d"}, "String", false, []string{"c", "b", "a"}},
+		{"containsAny", ComparatorContainsAny, []interface{}{"x", "b"}, "String", true, []string{"c", "b", "a"}},
+		{"containsAny none", ComparatorContainsAny, []interface{}{"x", "y"}, "String", false, []string{"c", "b", "a"}},
+		{"size", ComparatorSize, []interface{}{float64(3)}, "Number", true, []string{"c", "b", "a"}},
+		{"size mismatch", ComparatorSize, []interface{}{float64(2)}, "Number", false, []string{"c", "b", "a"}},
+		{"number list =", ComparatorEqual, []interface{}{float64(42)}, "Number", true, []int{7, 42}},
+		{"number list >", ComparatorGreater, []interface{}{float64(40)}, "Number", true, []interface{}{float64(7), float64(42)}},
+		{"number list containsAll", ComparatorContainsAll, []interface{}{float64(7), float64(42)}, "Number", true, []int64{42, 7}},
+		{"boolean list =", ComparatorEqual, []interface{}{true}, "Boolean", true, []bool{false, true}},
+		{"boolean list !=", ComparatorNotEqual, []interface{}{true}, "Boolean", true, []bool{false}},
+		{"elements of another type are ignored", ComparatorEqual, []interface{}{"42"}, "String", false, []interface{}{float64(42)}},
+		{"exist non-empty", ComparatorExist, []interface{}{}, "String", true, []string{"a"}},
+		{"exist empty", ComparatorExist, []interface{}{}, "String", false, []string{}},
+		{"!exist empty", ComparatorNotExist, []interface{}{}, "String", true, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testFilter := &CustomDataFilter{
+				UserFilter: &UserFilter{
+					filter: filter{
+						Type:       "user",
+						SubType:    "customData",
+						Comparator: test.comparator,
+					},
+					Values: test.values,
+				},
+				DataKey:     "listKey",
+				DataKeyType: test.dataKeyType,
+			}
+			require.NoError(t, testFilter.Initialize())
+
+			data := map[string]interface{}{"listKey": test.data}
+			require.Equal(t, test.expected, checkCustomData(testFilter, data, nil, time.Now()))
+
+			normalized := normalizeCustomDataMap(data)
+			require.IsType(t, []interface{}{}, normalized["listKey"])
+			require.Equal(t, test.expected, checkCustomData(testFilter, normalized, nil, time.Now()))
+		})
+	}
+}
+
+func TestNormalizeCustomDataMap(t *testing.T) {
+	data := map[string]interface{}{"scalar": "value"}
+	require.Equal(t, data, normalizeCustomDataMap(data))
+
+	data = map[string]interface{}{"scalar": "value", "list": []int{1, 2}}
+	normalized := normalizeCustomDataMap(data)
+	require.Equal(t, []interface{}{float64(1), float64(2)}, normalized["list"])
+	require.Equal(t, "value", normalized["scalar"])
+	require.Equal(t, []int{1, 2}, data["list"], "original map must not be modified")
+}
+
+func TestParseDataKeyPath(t *testing.T) {
+	require.Equal(t, []string{"plan"}, parseDataKeyPath("plan"))
+	require.Equal(t, []string{"org", "plan", "tier"}, parseDataKeyPath("org.plan.tier"))
+	require.Equal(t, []string{"domains", "example.com"}, parseDataKeyPath(`domains.example\.com`))
+	require.Equal(t, []string{`back\slash`, "x"}, parseDataKeyPath(`back\\slash.x`))
+	require.Equal(t, []string{"trailing", ""}, parseDataKeyPath("trailing."))
+}
+
+func TestCheckCustomData_NestedPath(t *testing.T) {
+	data := map[string]interface{}{
+		"org": map[string]interface{}{
+			"plan": map[string]interface{}{
+				"tier":  "enterprise",
+				"seats": float64(250),
+			},
+			"teams": []interface{}{
+				map[string]interface{}{"id": "team-1"},
+				map[string]interface{}{"id": "team-2"},
+			},
+		},
+		"domains": map[string]string{
+			"example.com": "verified",
+		},
+		"legacy.key": "top-level",
+	}
+
+	tests := []struct {
+		name        string
+		dataKey     string
+		dataKeyType string
+		comparator  string
+		values      []interface{}
+		expected    bool
+	}{
+		{"nested string", "org.plan.tier", "String", ComparatorEqual, []interface{}{"enterprise"}, true},
+		{"nested number", "org.plan.seats", "Number", ComparatorGreater, []interface{}{float64(100)}, true},
+		{"list index", "org.teams.1.id", "String", ComparatorEqual, []interface{}{"team-2"}, true},
+		{"list index out of range", "org.teams.5.id", "String", ComparatorExist, []interface{}{}, false},
+		{"escaped dot in typed map", `domains.example\.com`, "String", ComparatorEqual, []interface{}{"verified"}, true},
+		{"top-level key containing a dot", "legacy.key", "String", ComparatorEqual, []interface{}{"top-level"}, true},
+		{"missing intermediate", "org.billing.plan", "String", ComparatorNotExist, []interface{}{}, true},
+		{"path through a scalar", "org.plan.tier.name", "String", ComparatorExist, []interface{}{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testFilter := &CustomDataFilter{
+				UserFilter: &UserFilter{
+					filter: filter{
+						Type:       "user",
+						SubType:    "customData",
+						Comparator: test.comparator,
+					},
+					Values: test.values,
+				},
+				DataKey:     test.dataKey,
+				DataKeyType: test.dataKeyType,
+			}
+			require.NoError(t, testFilter.Initialize())
+			require.Equal(t, test.expected, checkCustomData(testFilter, data, nil, time.Now()))
+			require.Equal(t, test.expected, checkCustomData(testFilter, nil, data, time.Now()), "clientCustomData variation")
+		})
+	}
+}
+
+func TestCheckStringsFilter_CaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name            string
+		comparator      string
+		values          []interface{}
+		subject         string
+		caseInsensitive bool
+		expected        bool
+	}{
+		{"= case-sensitive by default", ComparatorEqual, []interface{}{"alice@corp.com"}, "Alice@Corp.com", false, false},
+		{"= case-insensitive", ComparatorEqual, []interface{}{"alice@corp.com"}, "Alice@Corp.com", true, true},
+		{"!= case-insensitive", ComparatorNotEqual, []interface{}{"alice@corp.com"}, "ALICE@CORP.COM", true, false},
+		{"contain case-insensitive", ComparatorContain, []interface{}{"@CORP."}, "alice@corp.com", true, true},
+		{"!contain case-insensitive", ComparatorNotContain, []interface{}{"@CORP."}, "alice@corp.com", true, false},
+		{"startWith case-insensitive", ComparatorStartWith, []interface{}{"ALICE"}, "alice@corp.com", true, true},
+		{"endWith case-insensitive", ComparatorEndWith, []interface{}{"Corp.Com"}, "alice@corp.com", true, true},
+		{"unicode folding", ComparatorEqual, []interface{}{"ÉMILIE"}, "émilie", true, true},
+		{"greek final sigma folding", ComparatorEqual, []interface{}{"ΟΔΟΣ"}, "οδος", true, true},
+		{"kelvin sign folding", ComparatorEqual, []interface{}{"Kelvin"}, "kelvin", true, true},
+		{"no match case-insensitive", ComparatorEqual, []interface{}{"bob@corp.com"}, "Alice@Corp.com", true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testFilter := &UserFilter{
+				filter: filter{
+					Type:       "user",
+					SubType:    "email",
+					Comparator: test.comparator,
+				},
+				Values:          test.values,
+				CaseInsensitive: test.caseInsensitive,
+			}
+			require.NoError(t, testFilter.Initialize())
+			require.Equal(t, test.expected, checkStringsFilter(test.subject, testFilter))
+		})
+	}
+}
+
+func TestCheckCustomData_CaseInsensitive(t *testing.T) {
+	testFilter := &CustomDataFilter{
+		UserFilter: &UserFilter{
+			filter: filter{
+				Type:       "user",
+				SubType:    "customData",
+				Comparator: ComparatorEqual,
+			},
+			Values:          []interface{}{"Enterprise"},
+			CaseInsensitive: true,
+		},
+		DataKey:     "plan",
+		DataKeyType: "String",
+	}
+	require.NoError(t, testFilter.Initialize())
+	require.True(t, checkCustomData(testFilter, map[string]interface{}{"plan": "ENTERPRISE"}, nil, time.Now()))
+	require.True(t, checkCustomData(testFilter, map[string]interface{}{"plan": []interface{}{"basic", "enterprise"}}, nil, time.Now()))
+
+	testFilter.Comparator = ComparatorContainsAll
+	require.True(t, checkCustomData(testFilter, map[string]interface{}{"plan": []interface{}{"basic", "enterprise"}}, nil, time.Now()))
+}
+
+func TestUserFilter_ParseCaseInsensitive(t *testing.T) {
+	var filters MixedFilters
+	err := json.Unmarshal([]byte(`[{"type": "user", "subType": "email", "comparator": "=", "values": ["Alice@Corp.com"], "caseInsensitive": true}]`), &filters)
+	require.NoError(t, err)
+	userFilter := filters[0].(*UserFilter)
+	require.True(t, userFilter.CaseInsensitive)
+	require.Equal(t, []string{foldString("alice@corp.com")}, userFilter.CompiledStringVals)
+}
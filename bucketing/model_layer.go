@@ -0,0 +1,20 @@
+package bucketing
+
+// A Layer groups mutually exclusive features. Each user is hashed once per layer, and only
+// qualifies for the feature whose allocation contains their hash.
+type Layer struct {
+	Id          string             `json:"_id" validate:"required"`
+	Key         string             `json:"key"`
+	Allocations []*LayerAllocation `json:"allocations" validate:"dive,required"`
+}
+
+// LayerAllocation allocates the traffic range [Start, End) of a layer to a feature.
+type LayerAllocation struct {
+	Feature string  `json:"_feature" validate:"required"`
+	Start   float64 `json:"start" validate:"gte=0,lte=1"`
+	End     float64 `json:"end" validate:"gte=0,lte=1,gtfield=Start"`
+}
+
+func (a *LayerAllocation) contains(hash float64) bool {
+	return hash >= a.Start && (hash < a.End || a.End == 1)
+}
@@ -10,6 +10,16 @@ type Target struct {
 	Rollout      *Rollout             `json:"rollout"`
 	Distribution []TargetDistribution `json:"distribution"`
 	BucketingKey string               `json:"bucketingKey"`
+	// BucketingKeys makes the bucketing value a composite of several keys, all of which the user
+	// must have. BucketingKey is ignored if set.
+	BucketingKeys []string `json:"bucketingKeys,omitempty"`
+	// BucketingKeyFallbacks are tried in order when the user has no value for the bucketing key
+	BucketingKeyFallbacks []string `json:"bucketingKeyFallbacks,omitempty"`
+	// ActiveWindow limits when the target can be matched, the target is always active if unset
+	ActiveWindow *TimeWindow `json:"activeWindow,omitempty"`
+
+	// bucketingKeyPaths holds the parsed paths of the custom data bucketing keys
+	bucketingKeyPaths map[string][]string
 }
 
 func (t *Target) DecideTargetVariation(boundedHash float64) (string, bool, error) {
@@ -39,12 +49,27 @@ type Rollout struct {
 	StartPercentage float64        `json:"startPercentage"`
 	StartDate       time.Time      `json:"startDate"`
 	Stages          []RolloutStage `json:"stages"`
+	// EndDate stops the rollout, serving no users from then on
+	EndDate *time.Time `json:"endDate,omitempty"`
 }
 
 type RolloutStage struct {
-	Type       string    `json:"type"`
+	Type       string    `json:"type" validate:"regexp=^(linear|discrete)$"`
 	Date       time.Time `json:"date"`
-	Percentage float64   `json:"percentage" validate:"regexp=^(linear|discrete)$"`
+	Percentage float64   `json:"percentage"`
+}
+
+// TimeWindow is the time range [Start, End), either bound being optional.
+type TimeWindow struct {
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+func (w *TimeWindow) contains(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	return (w.Start == nil || !t.Before(*w.Start)) && (w.End == nil || t.Before(*w.End))
 }
 
 type TargetDistribution struct {
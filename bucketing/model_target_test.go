@@ -128,8 +128,9 @@ func TestAudience_Parsing(t *testing.T) {
 								"h6fCse1VCIo1",
 							},
 						},
-						DataKey:     "data-key-6",
-						DataKeyType: "String",
+						DataKey:             "data-key-6",
+						DataKeyType:         "String",
+						CompiledDataKeyPath: []string{"data-key-6"},
 					},
 					&UserFilter{
 						filter: filter{
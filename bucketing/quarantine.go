@@ -0,0 +1,152 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/devcyclehq/go-server-sdk/v2/util"
+)
+
+// QuarantinedFeature is a feature that was left out of a config because it is invalid. The
+// variables of quarantined features default with api.DefaultReasonFeatureQuarantined.
+type QuarantinedFeature struct {
+	Id     string `json:"_id,omitempty"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// featureError is implemented by config validation errors that only affect some features.
+type featureError interface {
+	error
+	Features() []string
+}
+
+// SetQuarantineInvalidFeatures sets whether configs set with the given sdk key are loaded without
+// their invalid features, instead of being rejected.
+func SetQuarantineInvalidFeatures(sdkKey string, enabled bool) {
+	updateConfigOptions(sdkKey, func(options *configOptions) {
+		options.quarantineInvalidFeatures = enabled
+	})
+}
+
+// GetQuarantinedFeatures returns the features left out of the config set with the given sdk key.
+func GetQuarantinedFeatures(sdkKey string) []QuarantinedFeature {
+	config, err := getConfig(sdkKey)
+	if err != nil {
+		return nil
+	}
+	return config.quarantinedFeatures
+}
+
+// quarantineFeatureJSON is the part of a feature needed to quarantine it, which can be parsed
+// even if the rest of the feature can't.
+type quarantineFeatureJSON struct {
+	Id         string `json:"_id"`
+	Key        string `json:"key"`
+	Variations []struct {
+		Variables []struct {
+			Var string `json:"_var"`
+		} `json:"variables"`
+	} `json:"variations"`
+}
+
+// unmarshalQuarantiningFeatures parses the config, quarantining features that can't be parsed.
+// Audiences that can't be parsed are left out, which quarantines the features that reference them
+// once audience references are validated.
+func (c *configBody) unmarshalQuarantiningFeatures(configJSON []byte) error {
+	body := struct {
+		*configBody
+		Features  []json.RawMessage          `json:"features"`
+		Audiences map[string]json.RawMessage `json:"audiences"`
+	}{configBody: c}
+	if err := json.Unmarshal(configJSON, &body); err != nil {
+		return err
+	}
+
+	if body.Audiences != nil {
+		c.Audiences = make(map[string]NoIdAudience, len(body.Audiences))
+	}
+	for id, audienceJSON := range body.Audiences {
+		var audience NoIdAudience
+		if err := json.Unmarshal(audienceJSON, &audience); err != nil {
+			util.Warnf("Skipping invalid audience %s: %s", id, err)
+			continue
+		}
+		c.Audiences[id] = audience
+	}
+
+	if body.Features != nil {
+		c.Features = make([]*ConfigFeature, 0, len(body.Features))
+	}
+	for i, featureJSON := range body.Features {
+		var feature ConfigFeature
+		err := json.Unmarshal(featureJSON, &feature)
+		if err == nil {
+			c.Features = append(c.Features, &feature)
+			continue
+		}
+
+		var quarantined quarantineFeatureJSON
+		_ = json.Unmarshal(featureJSON, &quarantined)
+		if quarantined.Key == "" {
+			quarantined.Key = fmt.Sprintf("features[%d]", i)
+		}
+		var variableIds []string
+		for _, variation := range quarantined.Variations {
+			for _, variable := range variation.Variables {
+				variableIds = append(variableIds, variable.Var)
+			}
+		}
+		c.quarantine(QuarantinedFeature{Id: quarantined.Id, Key: quarantined.Key, Reason: err.Error()}, variableIds)
+	}
+	return nil
+}
+
+// quarantineFeatures removes the features with the given keys from the config, and returns how
+// many were removed.
+func (c *configBody) quarantineFeatures(keys []string, reason string) int {
+	quarantine := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		quarantine[key] = true
+	}
+	features := make([]*ConfigFeature, 0, len(c.Features))
+	removed := 0
+	for _, feature := range c.Features {
+		if !quarantine[feature.Key] {
+			features = append(features, feature)
+			continue
+		}
+		var variableIds []string
+		for _, variation := range feature.Variations {
+			for _, variable := range variation.Variables {
+				variableIds = append(variableIds, variable.Var)
+			}
+		}
+		c.quarantine(QuarantinedFeature{Id: feature.Id, Key: feature.Key, Reason: reason}, variableIds)
+		removed++
+	}
+	c.Features = features
+	return removed
+}
+
+func (c *configBody) quarantine(feature QuarantinedFeature, variableIds []string) {
+	util.Warnf("Quarantining invalid feature %s: %s", feature.Key, feature.Reason)
+	c.quarantinedFeatures = append(c.quarantinedFeatures, feature)
+	if c.quarantinedVariables == nil {
+		c.quarantinedVariables = make(map[string]string)
+	}
+	for _, variableId := range variableIds {
+		c.quarantinedVariables[variableId] = feature.Key
+	}
+
+	// The feature's layer allocation is left unused
+	for _, layer := range c.Layers {
+		allocations := make([]*LayerAllocation, 0, len(layer.Allocations))
+		for _, allocation := range layer.Allocations {
+			if allocation.Feature != feature.Id {
+				allocations = append(allocations, allocation)
+			}
+		}
+		layer.Allocations = allocations
+	}
+}
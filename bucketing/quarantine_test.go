@@ -0,0 +1,86 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// quarantineConfig builds a config JSON with a valid "good" feature, a "broken-filter" feature
+// whose filter values can't be compiled, a "dependent" feature with "broken-filter" as a
+// prerequisite, a "broken-audience" feature referencing an audience that can't be parsed, and a
+// "broken-schedule" feature whose active window ends before it starts.
+func quarantineConfig(t *testing.T) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{
+		{key: "good", variation: "on"},
+		{key: "broken-filter", variation: "on"},
+		{key: "dependent", variation: "on", prerequisites: []prerequisiteFilter{{feature: "broken-filter", comparator: "="}}},
+		{key: "broken-audience", variation: "on"},
+		{key: "broken-schedule", variation: "on"},
+	}), &config))
+	config["audiences"] = map[string]interface{}{
+		"broken": map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{
+			map[string]interface{}{"type": "user", "subType": "email", "comparator": "=", "values": []interface{}{"a", 1}},
+		}}},
+	}
+	features := config["features"].([]interface{})
+	target := func(i int) map[string]interface{} {
+		return features[i].(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+	}
+	setTargetFilter(target(1), map[string]interface{}{"type": "user", "subType": "email", "comparator": "=", "values": []interface{}{true, "a"}})
+	setTargetFilter(target(3), map[string]interface{}{"type": "audienceMatch", "comparator": "=", "_audiences": []interface{}{"broken"}})
+	target(4)["activeWindow"] = map[string]interface{}{"start": "2024-01-02T00:00:00Z", "end": "2024-01-01T00:00:00Z"}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestSetConfig_QuarantineInvalidFeatures(t *testing.T) {
+	sdkKey := "dvc_server_quarantine"
+	require.Error(t, SetConfig(quarantineConfig(t), sdkKey, "", "", ""), "configs with invalid features are rejected by default")
+
+	SetQuarantineInvalidFeatures(sdkKey, true)
+	defer SetQuarantineInvalidFeatures(sdkKey, false)
+	require.NoError(t, SetConfig(quarantineConfig(t), sdkKey, "", "", ""))
+
+	quarantined := make(map[string]string)
+	for _, feature := range GetQuarantinedFeatures(sdkKey) {
+		quarantined[feature.Key] = feature.Reason
+	}
+	require.Len(t, quarantined, 4)
+	require.Contains(t, quarantined["broken-filter"], "filter values must be all of the same type")
+	require.Contains(t, quarantined["dependent"], "missing prerequisite feature broken-filter")
+	require.Contains(t, quarantined["broken-audience"], "broken")
+	require.Contains(t, quarantined["broken-schedule"], "active window ending before it starts")
+
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+	_, value, _, _, _, err := VariableForUser(sdkKey, user, "var-good", VariableTypesBool, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+	for featureKey := range quarantined {
+		_, _, _, evalReason, evalDetails, err := VariableForUser(sdkKey, user, "var-"+featureKey, VariableTypesBool, nil, nil)
+		require.ErrorIs(t, err, ErrFeatureQuarantined, featureKey)
+		require.Equal(t, api.EvaluationReasonDefault, evalReason)
+		require.Equal(t, string(api.DefaultReasonFeatureQuarantined), evalDetails)
+	}
+}
+
+func TestSetConfig_QuarantineInvalidFeatures_ConfigErrors(t *testing.T) {
+	sdkKey := "dvc_server_quarantine_config_errors"
+	SetQuarantineInvalidFeatures(sdkKey, true)
+	defer SetQuarantineInvalidFeatures(sdkKey, false)
+
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "a", variation: "on"}}), &config))
+	config["layers"] = []interface{}{
+		map[string]interface{}{"_id": "layer", "key": "layer", "allocations": []interface{}{}},
+		map[string]interface{}{"_id": "layer", "key": "layer", "allocations": []interface{}{}},
+	}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.ErrorContains(t, SetConfig(configJSON, sdkKey, "", "", ""), "duplicate layer", "problems outside features still reject the config")
+}
@@ -0,0 +1,50 @@
+package bucketing
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduleError is returned for a target whose active window or rollout ends before it starts.
+type ScheduleError struct {
+	Feature string
+	Target  string
+	Message string
+}
+
+func (e *ScheduleError) Error() string {
+	return e.Message
+}
+
+// Features returns the key of the feature with the invalid schedule.
+func (e *ScheduleError) Features() []string {
+	return []string{e.Feature}
+}
+
+// validateSchedules checks that every target's active window and rollout end date come after
+// the times they start, including all rollout stages.
+func validateSchedules(config *configBody) error {
+	for _, feature := range config.Features {
+		for _, target := range feature.Configuration.Targets {
+			scheduleError := func(format string, args ...interface{}) error {
+				return &ScheduleError{Feature: feature.Key, Target: target.Id, Message: fmt.Sprintf(format, args...)}
+			}
+			if window := target.ActiveWindow; window != nil && window.Start != nil && window.End != nil && !window.End.After(*window.Start) {
+				return scheduleError("target %s of feature %s has an active window ending before it starts", target.Id, feature.Key)
+			}
+			rollout := target.Rollout
+			if rollout == nil || rollout.EndDate == nil {
+				continue
+			}
+			if !rollout.EndDate.After(rollout.StartDate) {
+				return scheduleError("rollout of target %s of feature %s ends before it starts", target.Id, feature.Key)
+			}
+			for _, stage := range rollout.Stages {
+				if !rollout.EndDate.After(stage.Date) {
+					return scheduleError("rollout of target %s of feature %s ends before its stage at %s", target.Id, feature.Key, stage.Date.Format(time.RFC3339))
+				}
+			}
+		}
+	}
+	return nil
+}
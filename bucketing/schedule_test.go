@@ -0,0 +1,124 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+var scheduleStart = time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+func scheduleTime(hours int) *time.Time {
+	t := scheduleStart.Add(time.Duration(hours) * time.Hour)
+	return &t
+}
+
+// activeWindowConfig builds a config JSON with a "promotion" feature targeting everyone with its
+// "on" variation during the window, followed by a target serving "off" to everyone.
+func activeWindowConfig(t *testing.T, window *TimeWindow) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "promotion", variation: "on"}}), &config))
+	configuration := config["features"].([]interface{})[0].(map[string]interface{})["configuration"].(map[string]interface{})
+	targets := configuration["targets"].([]interface{})
+	target := targets[0].(map[string]interface{})
+	target["activeWindow"] = window
+	fallback := map[string]interface{}{
+		"_id":          "fallback",
+		"_audience":    target["_audience"],
+		"distribution": []interface{}{map[string]interface{}{"_variation": "off", "percentage": 1}},
+	}
+	configuration["targets"] = append(targets, fallback)
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestIsUserInRollout_EndDate(t *testing.T) {
+	rollouts := map[string]Rollout{
+		"schedule": {Type: "schedule", StartDate: scheduleStart, EndDate: scheduleTime(10)},
+		"gradual": {
+			Type:            "gradual",
+			StartPercentage: 1,
+			StartDate:       scheduleStart,
+			Stages:          []RolloutStage{{Type: "discrete", Date: *scheduleTime(5), Percentage: 1}},
+			EndDate:         scheduleTime(10),
+		},
+	}
+	for name, rollout := range rollouts {
+		t.Run(name, func(t *testing.T) {
+			require.True(t, isUserInRollout(rollout, 0.5, *scheduleTime(9)))
+			require.False(t, isUserInRollout(rollout, 0.5, *scheduleTime(10)))
+			require.False(t, isUserInRollout(rollout, 0.5, *scheduleTime(11)))
+		})
+	}
+}
+
+func TestVariableForUserAt_ActiveWindow(t *testing.T) {
+	sdkKey := "dvc_server_active_window"
+	require.NoError(t, SetConfig(activeWindowConfig(t, &TimeWindow{Start: scheduleTime(0), End: scheduleTime(24)}), sdkKey, "", "", ""))
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		value interface{}
+	}{
+		{name: "before window", at: *scheduleTime(-1), value: false},
+		{name: "window start", at: *scheduleTime(0), value: true},
+		{name: "during window", at: *scheduleTime(12), value: true},
+		{name: "window end", at: *scheduleTime(24), value: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, value, _, _, _, err := VariableForUserAt(sdkKey, user, "var-promotion", VariableTypesBool, nil, nil, test.at)
+			require.NoError(t, err)
+			require.Equal(t, test.value, value)
+		})
+	}
+}
+
+func TestValidateSchedules(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  []byte
+		wantErr string
+	}{
+		{
+			name:   "open window",
+			config: activeWindowConfig(t, &TimeWindow{End: scheduleTime(1)}),
+		},
+		{
+			name:    "window ends before start",
+			config:  activeWindowConfig(t, &TimeWindow{Start: scheduleTime(1), End: scheduleTime(0)}),
+			wantErr: "active window ending before it starts",
+		},
+		{
+			name:   "rollout ends after stages",
+			config: rolloutConfig(t, Rollout{Type: "gradual", StartDate: scheduleStart, Stages: []RolloutStage{{Type: "linear", Date: *scheduleTime(5), Percentage: 1}}, EndDate: scheduleTime(6)}),
+		},
+		{
+			name:    "rollout ends before start",
+			config:  rolloutConfig(t, Rollout{Type: "schedule", StartDate: scheduleStart, EndDate: scheduleTime(0)}),
+			wantErr: "ends before it starts",
+		},
+		{
+			name:    "rollout ends before stage",
+			config:  rolloutConfig(t, Rollout{Type: "gradual", StartDate: scheduleStart, Stages: []RolloutStage{{Type: "linear", Date: *scheduleTime(5), Percentage: 1}}, EndDate: scheduleTime(4)}),
+			wantErr: "ends before its stage",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newConfig(test.config, "", "", "")
+			if test.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.wantErr)
+			}
+		})
+	}
+}
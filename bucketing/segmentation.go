@@ -1,11 +1,15 @@
 package bucketing
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/devcyclehq/go-server-sdk/v2/util"
 	"math"
+	"net/netip"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/devcyclehq/go-server-sdk/v2/api"
 )
@@ -26,6 +30,61 @@ func filterForAudienceMatch(filter *AudienceMatchFilter, configAudiences map[str
 	return comparator == "!="
 }
 
+// evaluateFilter evaluates the filter the same way as FilterOrOperator.Evaluate, except that
+// custom data filters are evaluated at the context's evaluation time and prerequisite filters
+// bucket the user into the prerequisite feature of the config.
+func evaluateFilter(f FilterOrOperator, config *configBody, ctx *evaluationContext) bool {
+	switch filter := f.(type) {
+	case *AudienceOperator:
+		if filter == nil {
+			return false
+		}
+		return evaluateOperator(*filter, config, ctx)
+	case AudienceOperator:
+		return evaluateOperator(filter, config, ctx)
+	case *CustomDataFilter:
+		return checkCustomData(filter, ctx.mergedCustomData, ctx.clientCustomData, ctx.now)
+	case *PrerequisiteFilter:
+		return prerequisiteMet(config, filter, ctx)
+	case *AudienceMatchFilter:
+		for _, audience := range filter.Audiences {
+			a, ok := config.Audiences[audience]
+			if !ok {
+				return false
+			}
+			if evaluateFilter(a.Filters, config, ctx) {
+				return filter.GetComparator() == "="
+			}
+		}
+		return filter.GetComparator() == "!="
+	default:
+		return f.Evaluate(config.Audiences, ctx.user, ctx.clientCustomData)
+	}
+}
+
+func evaluateOperator(operator AudienceOperator, config *configBody, ctx *evaluationContext) bool {
+	if len(operator.Filters) == 0 {
+		return false
+	}
+	switch operator.Operator {
+	case OperatorOr:
+		for _, filter := range operator.Filters {
+			if evaluateFilter(filter, config, ctx) {
+				return true
+			}
+		}
+		return false
+	case OperatorAnd:
+		for _, filter := range operator.Filters {
+			if !evaluateFilter(filter, config, ctx) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func filterFunctionsBySubtype(filter *UserFilter, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
 	switch filter.SubType {
 	case SubTypeCountry:
@@ -42,21 +101,40 @@ func filterFunctionsBySubtype(filter *UserFilter, user api.PopulatedUser, client
 		return checkStringsFilter(user.User.DeviceModel, filter)
 	case SubTypePlatform:
 		return checkStringsFilter(user.Platform, filter)
+	case SubTypeIP:
+		return checkIPFilter(user.IP, filter)
+	case SubTypeLanguage:
+		return checkLanguageFilter(user.Language, filter)
+	case SubTypeName:
+		return checkStringsFilter(user.Name, filter)
 	default:
 		return false
 	}
 }
 
-func checkCustomData(filter *CustomDataFilter, data map[string]interface{}, clientCustomData map[string]interface{}) bool {
+// checkCustomData evaluates a custom data filter, with relative date comparators evaluated
+// against currentDate.
+func checkCustomData(filter *CustomDataFilter, data map[string]interface{}, clientCustomData map[string]interface{}, currentDate time.Time) bool {
 	operator := filter.GetComparator()
 	var dataValue interface{}
 
-	if _, ok := data[filter.DataKey]; !ok {
-		if v2, ok2 := clientCustomData[filter.DataKey]; ok2 {
-			dataValue = v2
-		}
-	} else {
-		dataValue = data[filter.DataKey]
+	path := filter.CompiledDataKeyPath
+	if path == nil {
+		path = parseDataKeyPath(filter.DataKey)
+	}
+	if v, ok := lookupCustomDataPath(data, filter.DataKey, path); ok {
+		dataValue = v
+	} else if v2, ok2 := lookupCustomDataPath(clientCustomData, filter.DataKey, path); ok2 {
+		dataValue = v2
+	}
+	if filter.DataKeyType == DataKeyTypeDate {
+		return checkDateFilter(dataValue, filter.UserFilter, currentDate)
+	}
+	// Lists are normalized once per evaluation, other slice types only reach here when called directly
+	if list, ok := dataValue.([]interface{}); ok {
+		return checkListFilter(list, filter)
+	} else if list, ok := normalizeCustomDataList(dataValue); ok {
+		return checkListFilter(list, filter)
 	}
 	isNot64Bit := false
 	switch dataValue.(type) {
@@ -103,6 +181,223 @@ func checkCustomData(filter *CustomDataFilter, data map[string]interface{}, clie
 	return false
 }
 
+// parseDateValue accepts an RFC3339 string, a time.Time or a number of epoch milliseconds of any
+// numeric type, including json.Number.
+func parseDateValue(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, !v.IsZero()
+	case string:
+		date, err := time.Parse(time.RFC3339, v)
+		return date, err == nil
+	case json.Number:
+		if millis, err := v.Int64(); err == nil {
+			return time.UnixMilli(millis), true
+		}
+		millis, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return parseDateValue(millis)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(v)), true
+	case float32:
+		return parseDateValue(float64(v))
+	case int:
+		return time.UnixMilli(int64(v)), true
+	case int8:
+		return time.UnixMilli(int64(v)), true
+	case int16:
+		return time.UnixMilli(int64(v)), true
+	case int32:
+		return time.UnixMilli(int64(v)), true
+	case int64:
+		return time.UnixMilli(v), true
+	case uint:
+		return parseDateValue(uint64(v))
+	case uint8:
+		return time.UnixMilli(int64(v)), true
+	case uint16:
+		return time.UnixMilli(int64(v)), true
+	case uint32:
+		return time.UnixMilli(int64(v)), true
+	case uint64:
+		if v > math.MaxInt64 {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(v)), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// checkDateFilter evaluates a Date custom data filter. Values that can't be parsed as a date are
+// treated as missing. Relative comparators are evaluated against currentDate.
+func checkDateFilter(dataValue interface{}, filter *UserFilter, currentDate time.Time) bool {
+	operator := filter.GetComparator()
+	date, ok := parseDateValue(dataValue)
+	if operator == ComparatorExist {
+		return ok
+	} else if operator == ComparatorNotExist {
+		return !ok
+	} else if !ok {
+		return false
+	}
+
+	values := filter.CompiledDateVals
+	switch operator {
+	case ComparatorEqual:
+		return dateArrayIn(values, date)
+	case ComparatorNotEqual:
+		return !dateArrayIn(values, date)
+	case ComparatorBefore:
+		for _, value := range values {
+			if date.Before(value) {
+				return true
+			}
+		}
+		return false
+	case ComparatorAfter:
+		for _, value := range values {
+			if date.After(value) {
+				return true
+			}
+		}
+		return false
+	case ComparatorBetween:
+		return len(values) == 2 && !date.Before(values[0]) && !date.After(values[1])
+	case ComparatorWithinLast, ComparatorWithinNext:
+		for _, days := range filter.CompiledNumVals {
+			window := time.Duration(days * float64(24*time.Hour))
+			if operator == ComparatorWithinLast && !date.After(currentDate) && !date.Before(currentDate.Add(-window)) {
+				return true
+			}
+			if operator == ComparatorWithinNext && !date.Before(currentDate) && !date.After(currentDate.Add(window)) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func dateArrayIn(dates []time.Time, search time.Time) bool {
+	for _, date := range dates {
+		if date.Equal(search) {
+			return true
+		}
+	}
+	return false
+}
+
+// negatedComparators maps each negated comparator to its positive form. A negated comparator
+// passes for a list only if no element passes the positive comparator.
+var negatedComparators = map[string]string{
+	ComparatorNotEqual:     ComparatorEqual,
+	ComparatorNotContain:   ComparatorContain,
+	ComparatorNotStartWith: ComparatorStartWith,
+	ComparatorNotEndWith:   ComparatorEndWith,
+}
+
+// checkListFilter evaluates a custom data filter against a list value. Scalar comparators pass
+// if any element of the list passes, elements not matching the filter's DataKeyType are ignored.
+func checkListFilter(list []interface{}, filter *CustomDataFilter) bool {
+	operator := filter.GetComparator()
+	switch operator {
+	case ComparatorExist:
+		return len(list) > 0
+	case ComparatorNotExist:
+		return len(list) == 0
+	case ComparatorSize:
+		size := float64(len(list))
+		for _, value := range filter.CompiledNumVals {
+			if value == size {
+				return true
+			}
+		}
+		return false
+	case ComparatorContainsAny:
+		for _, value := range filter.Values {
+			if listContainsFilterValue(list, value, filter.CaseInsensitive) {
+				return true
+			}
+		}
+		return false
+	case ComparatorContainsAll:
+		if len(filter.Values) == 0 {
+			return false
+		}
+		for _, value := range filter.Values {
+			if !listContainsFilterValue(list, value, filter.CaseInsensitive) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if positive, ok := negatedComparators[operator]; ok {
+		return !listElementsMatch(list, filter, positive)
+	}
+	return listElementsMatch(list, filter, operator)
+}
+
+func listElementsMatch(list []interface{}, filter *CustomDataFilter, operator string) bool {
+	for _, element := range list {
+		switch v := element.(type) {
+		case string:
+			if filter.CaseInsensitive {
+				v = foldString(v)
+			}
+			if filter.DataKeyType == DataKeyTypeString && checkStringValues(v, filter.CompiledStringVals, operator) {
+				return true
+			}
+		case float64:
+			if filter.DataKeyType == DataKeyTypeNumber && _checkNumberFilter(v, filter.CompiledNumVals, operator) {
+				return true
+			}
+		case bool:
+			if filter.DataKeyType == DataKeyTypeBoolean && operator == ComparatorEqual && listContainsValue(filter.Values, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func listContainsFilterValue(list []interface{}, value interface{}, caseInsensitive bool) bool {
+	value = normalizeCustomDataScalar(value)
+	if str, ok := value.(string); ok && caseInsensitive {
+		for _, element := range list {
+			if elementStr, ok := element.(string); ok && strings.EqualFold(elementStr, str) {
+				return true
+			}
+		}
+		return false
+	}
+	return listContainsValue(list, value)
+}
+
+func listContainsValue(list []interface{}, value interface{}) bool {
+	for _, element := range list {
+		switch v := element.(type) {
+		case string, float64, bool:
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func checkNumbersFilterJSONValue(jsonValue interface{}, filter *UserFilter) bool {
 	return _checkNumbersFilter(jsonValue.(float64), filter)
 }
@@ -114,8 +409,27 @@ func _checkNumbersFilter(number float64, filter *UserFilter) bool {
 }
 
 func checkStringsFilter(str string, filter *UserFilter) bool {
-	operator := filter.GetComparator()
-	values := filter.CompiledStringVals
+	if filter.CaseInsensitive {
+		str = foldString(str)
+	}
+	return checkStringValues(str, filter.CompiledStringVals, filter.GetComparator())
+}
+
+// foldString maps every rune to the smallest rune in its Unicode simple case folding orbit,
+// so that two strings are equal after folding exactly when strings.EqualFold reports true.
+func foldString(str string) string {
+	return strings.Map(func(r rune) rune {
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		return folded
+	}, str)
+}
+
+func checkStringValues(str string, values []string, operator string) bool {
 	if operator == ComparatorEqual {
 		return str != "" && stringArrayIn(values, str)
 	} else if operator == ComparatorNotEqual {
@@ -141,6 +455,74 @@ func checkStringsFilter(str string, filter *UserFilter) bool {
 	}
 }
 
+// checkIPFilter matches an IP address against the filter's compiled prefixes. Exact
+// address values are single-address prefixes, so = and contain share the same check.
+func checkIPFilter(ip string, filter *UserFilter) bool {
+	operator := filter.GetComparator()
+	if operator == ComparatorExist {
+		return ip != ""
+	} else if operator == ComparatorNotExist {
+		return ip == ""
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+
+	switch operator {
+	case ComparatorEqual, ComparatorContain:
+		return ipPrefixesContain(filter.CompiledIPPrefixes, addr)
+	case ComparatorNotEqual, ComparatorNotContain:
+		return !ipPrefixesContain(filter.CompiledIPPrefixes, addr)
+	default:
+		return false
+	}
+}
+
+func ipPrefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLanguageTag lowercases a language tag and uses "-" as the subtag separator, so that
+// "en_GB", "EN-gb" and "en-GB" compare equal.
+func normalizeLanguageTag(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
+
+// checkLanguageFilter compares languages case-insensitively. For = and != a filter value without
+// a region subtag also matches any regional variant, e.g. "en" matches "en-GB".
+func checkLanguageFilter(language string, filter *UserFilter) bool {
+	operator := filter.GetComparator()
+	language = normalizeLanguageTag(language)
+	switch operator {
+	case ComparatorEqual:
+		return language != "" && languageArrayMatches(filter.CompiledStringVals, language)
+	case ComparatorNotEqual:
+		return language != "" && !languageArrayMatches(filter.CompiledStringVals, language)
+	default:
+		return checkStringValues(language, filter.CompiledStringVals, operator)
+	}
+}
+
+func languageArrayMatches(tags []string, language string) bool {
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		if language == tag || strings.HasPrefix(language, tag+"-") {
+			return true
+		}
+	}
+	return false
+}
+
 func stringArrayIn(arr []string, search string) bool {
 	for _, s := range arr {
 		if s == search {
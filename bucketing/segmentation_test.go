@@ -1,6 +1,7 @@
 package bucketing
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 
@@ -1876,3 +1877,164 @@ func TestDoesUserPassFilter_WithUserPlatformFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestDoesUserPassFilter_WithUserIPFilter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		ip         string
+		comparator string
+		values     []interface{}
+		expected   bool
+	}{
+		{name: "IPv4 exact match", ip: "192.168.1.10", comparator: ComparatorEqual, values: []interface{}{"192.168.1.10"}, expected: true},
+		{name: "IPv4 exact mismatch", ip: "192.168.1.11", comparator: ComparatorEqual, values: []interface{}{"192.168.1.10"}, expected: false},
+		{name: "IPv4 in CIDR", ip: "10.20.30.40", comparator: ComparatorEqual, values: []interface{}{"172.16.0.0/12", "10.0.0.0/8"}, expected: true},
+		{name: "IPv4 outside CIDR", ip: "11.0.0.1", comparator: ComparatorEqual, values: []interface{}{"10.0.0.0/8"}, expected: false},
+		{name: "IPv4 in CIDR with contain", ip: "10.20.30.40", comparator: ComparatorContain, values: []interface{}{"10.0.0.0/8"}, expected: true},
+		{name: "IPv4 not in CIDR", ip: "11.0.0.1", comparator: ComparatorNotEqual, values: []interface{}{"10.0.0.0/8"}, expected: true},
+		{name: "IPv4 in CIDR with !=", ip: "10.0.0.1", comparator: ComparatorNotEqual, values: []interface{}{"10.0.0.0/8"}, expected: false},
+		{name: "IPv4-mapped IPv6 user matches IPv4 CIDR", ip: "::ffff:10.1.2.3", comparator: ComparatorEqual, values: []interface{}{"10.0.0.0/8"}, expected: true},
+		{name: "IPv4 user matches IPv4-mapped CIDR", ip: "10.1.2.3", comparator: ComparatorEqual, values: []interface{}{"::ffff:10.0.0.0/104"}, expected: true},
+		{name: "IPv6 exact match", ip: "2001:db8::1", comparator: ComparatorEqual, values: []interface{}{"2001:DB8::1"}, expected: true},
+		{name: "IPv6 in CIDR", ip: "2001:db8:abcd::1", comparator: ComparatorEqual, values: []interface{}{"2001:db8::/32"}, expected: true},
+		{name: "IPv6 outside CIDR", ip: "2001:db9::1", comparator: ComparatorEqual, values: []interface{}{"2001:db8::/32"}, expected: false},
+		{name: "IPv6 does not match IPv4 CIDR", ip: "2001:db8::1", comparator: ComparatorEqual, values: []interface{}{"0.0.0.0/0"}, expected: false},
+		{name: "invalid user IP", ip: "not-an-ip", comparator: ComparatorEqual, values: []interface{}{"0.0.0.0/0"}, expected: false},
+		{name: "invalid user IP with !=", ip: "not-an-ip", comparator: ComparatorNotEqual, values: []interface{}{"10.0.0.0/8"}, expected: false},
+		{name: "missing IP with =", ip: "", comparator: ComparatorEqual, values: []interface{}{"0.0.0.0/0"}, expected: false},
+		{name: "IP exists", ip: "10.0.0.1", comparator: ComparatorExist, values: []interface{}{}, expected: true},
+		{name: "IP does not exist", ip: "", comparator: ComparatorExist, values: []interface{}{}, expected: false},
+		{name: "IP !exist", ip: "", comparator: ComparatorNotExist, values: []interface{}{}, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := api.PopulatedUser{
+				User: api.User{
+					UserId: "1234",
+					IP:     tc.ip,
+				},
+			}
+			testFilter := &UserFilter{
+				filter: filter{
+					Type:       "user",
+					SubType:    "ip",
+					Comparator: tc.comparator,
+				},
+				Values: tc.values,
+			}
+			require.NoError(t, testFilter.Initialize())
+			require.Equal(t, tc.expected, testFilter.Evaluate(nil, user, nil))
+		})
+	}
+}
+
+func TestUserFilter_InitializeInvalidIP(t *testing.T) {
+	testFilter := &UserFilter{
+		filter: filter{
+			Type:       "user",
+			SubType:    "ip",
+			Comparator: ComparatorEqual,
+		},
+		Values: []interface{}{"10.0.0.0/33"},
+	}
+	require.Error(t, testFilter.Initialize())
+
+	testFilter.Values = []interface{}{"10.0.0"}
+	require.Error(t, testFilter.Initialize())
+}
+
+func TestDoesUserPassFilter_WithUserLanguageFilter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		language   string
+		comparator string
+		values     []interface{}
+		expected   bool
+	}{
+		{name: "exact match", language: "en", comparator: ComparatorEqual, values: []interface{}{"en"}, expected: true},
+		{name: "case-insensitive match", language: "EN", comparator: ComparatorEqual, values: []interface{}{"en"}, expected: true},
+		{name: "language matches regional variant", language: "en-GB", comparator: ComparatorEqual, values: []interface{}{"en"}, expected: true},
+		{name: "underscore region separator", language: "en_gb", comparator: ComparatorEqual, values: []interface{}{"en"}, expected: true},
+		{name: "region matches case-insensitively", language: "en-gb", comparator: ComparatorEqual, values: []interface{}{"en-GB"}, expected: true},
+		{name: "region does not match other region", language: "en-US", comparator: ComparatorEqual, values: []interface{}{"en-GB"}, expected: false},
+		{name: "region filter does not match bare language", language: "en", comparator: ComparatorEqual, values: []interface{}{"en-GB"}, expected: false},
+		{name: "prefix is not a subtag", language: "eng", comparator: ComparatorEqual, values: []interface{}{"en"}, expected: false},
+		{name: "match in list", language: "fr-CA", comparator: ComparatorEqual, values: []interface{}{"de", "fr"}, expected: true},
+		{name: "not equal to other language", language: "fr", comparator: ComparatorNotEqual, values: []interface{}{"en"}, expected: true},
+		{name: "not equal excludes regional variant", language: "en-AU", comparator: ComparatorNotEqual, values: []interface{}{"en"}, expected: false},
+		{name: "missing language with not equal", language: "", comparator: ComparatorNotEqual, values: []interface{}{"en"}, expected: false},
+		{name: "exists", language: "en", comparator: ComparatorExist, values: []interface{}{}, expected: true},
+		{name: "does not exist", language: "", comparator: ComparatorNotExist, values: []interface{}{}, expected: true},
+		{name: "startWith is case-insensitive", language: "PT-BR", comparator: ComparatorStartWith, values: []interface{}{"pt"}, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := api.PopulatedUser{User: api.User{UserId: "1234", Language: tc.language}}
+			testFilter := &UserFilter{
+				filter: filter{
+					Type:       "user",
+					SubType:    "language",
+					Comparator: tc.comparator,
+				},
+				Values: tc.values,
+			}
+			require.NoError(t, testFilter.Initialize())
+			require.Equal(t, tc.expected, testFilter.Evaluate(nil, user, nil))
+		})
+	}
+}
+
+// The name filter has the same semantics as the other plain string user filters.
+func TestDoesUserPassFilter_WithUserNameFilterParity(t *testing.T) {
+	testCases := []struct {
+		value      string
+		comparator string
+		values     []interface{}
+	}{
+		{value: "Jane Doe", comparator: ComparatorEqual, values: []interface{}{"Jane Doe"}},
+		{value: "Jane Doe", comparator: ComparatorEqual, values: []interface{}{"jane doe"}},
+		{value: "Jane Doe", comparator: ComparatorNotEqual, values: []interface{}{"John Doe"}},
+		{value: "", comparator: ComparatorNotEqual, values: []interface{}{"John Doe"}},
+		{value: "Jane Doe", comparator: ComparatorContain, values: []interface{}{"Doe"}},
+		{value: "Jane Doe", comparator: ComparatorNotContain, values: []interface{}{"Doe"}},
+		{value: "Jane Doe", comparator: ComparatorStartWith, values: []interface{}{"Jane"}},
+		{value: "Jane Doe", comparator: ComparatorEndWith, values: []interface{}{"Jane"}},
+		{value: "", comparator: ComparatorExist, values: []interface{}{}},
+		{value: "", comparator: ComparatorNotExist, values: []interface{}{}},
+	}
+
+	for _, tc := range testCases {
+		nameFilter := &UserFilter{
+			filter: filter{Type: "user", SubType: "name", Comparator: tc.comparator},
+			Values: tc.values,
+		}
+		require.NoError(t, nameFilter.Initialize())
+		emailFilter := &UserFilter{
+			filter: filter{Type: "user", SubType: "email", Comparator: tc.comparator},
+			Values: tc.values,
+		}
+		require.NoError(t, emailFilter.Initialize())
+
+		expected := emailFilter.Evaluate(nil, api.PopulatedUser{User: api.User{Email: tc.value}}, nil)
+		actual := nameFilter.Evaluate(nil, api.PopulatedUser{User: api.User{Name: tc.value}}, nil)
+		require.Equal(t, expected, actual, "name %q %s %v", tc.value, tc.comparator, tc.values)
+	}
+}
+
+func TestMixedFilters_ParseLanguageAndName(t *testing.T) {
+	var filters MixedFilters
+	err := json.Unmarshal([]byte(`[
+		{"type": "user", "subType": "language", "comparator": "=", "values": ["EN"]},
+		{"type": "user", "subType": "name", "comparator": "startWith", "values": ["Jane"]}
+	]`), &filters)
+	require.NoError(t, err)
+
+	operator := AudienceOperator{Operator: OperatorAnd, Filters: filters}
+	user := api.PopulatedUser{User: api.User{UserId: "1234", Language: "en-CA", Name: "Jane Doe"}}
+	require.True(t, operator.Evaluate(nil, user, nil))
+
+	user.Language = "fr-CA"
+	require.False(t, operator.Evaluate(nil, user, nil))
+}
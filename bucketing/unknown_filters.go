@@ -0,0 +1,194 @@
+package bucketing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// UnknownFilterPolicy decides how filters with a type this SDK doesn't support are evaluated.
+type UnknownFilterPolicy int
+
+const (
+	// UnknownFilterFailClosed stops targets that depend on an unknown filter from matching.
+	UnknownFilterFailClosed UnknownFilterPolicy = iota
+	// UnknownFilterFailOpen evaluates unknown filters as passing.
+	UnknownFilterFailOpen
+	// UnknownFilterReject rejects configs with unknown filters that any target depends on.
+	UnknownFilterReject
+)
+
+// SetUnknownFilterPolicy sets the policy used for configs set with the given sdk key.
+func SetUnknownFilterPolicy(sdkKey string, policy UnknownFilterPolicy) {
+	updateConfigOptions(sdkKey, func(options *configOptions) {
+		options.unknownFilterPolicy = policy
+	})
+}
+
+// UnknownFilter is a filter with a type this SDK doesn't support. It passes only under the
+// UnknownFilterFailOpen policy.
+//
+// MixedFilters.UnmarshalJSON keeps it in place of the skipped filter instead of dropping it:
+// the policy is only known once the config is set for an sdk key, fail closed needs to know
+// which targets depend on the filter, and dropping a passing operand from an "or" operator
+// would change its result under fail open.
+type UnknownFilter struct {
+	filter
+
+	pass bool
+}
+
+func (f *UnknownFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
+	return f.pass
+}
+
+// UnknownFilterOccurrence is an unknown filter found in a config.
+type UnknownFilterOccurrence struct {
+	// Path is the audience or target containing the filter, e.g. features[my-feature].targets[target-id]
+	Path string `json:"path"`
+	Type string `json:"type"`
+	// Features are the keys of the features with a target that depends on the filter
+	Features []string `json:"features,omitempty"`
+}
+
+// UnknownFilterError is returned when a config with unknown filters is set under the
+// UnknownFilterReject policy.
+type UnknownFilterError struct {
+	Occurrences []UnknownFilterOccurrence
+}
+
+func (e *UnknownFilterError) Error() string {
+	occurrences := make([]string, len(e.Occurrences))
+	for i, occurrence := range e.Occurrences {
+		occurrences[i] = fmt.Sprintf("%q in %s", occurrence.Type, occurrence.Path)
+	}
+	return "unknown filter types: " + strings.Join(occurrences, ", ")
+}
+
+// Features returns the keys of every feature with a target that depends on an unknown filter.
+func (e *UnknownFilterError) Features() []string {
+	seen := make(map[string]bool)
+	var features []string
+	for _, occurrence := range e.Occurrences {
+		for _, feature := range occurrence.Features {
+			if !seen[feature] {
+				seen[feature] = true
+				features = append(features, feature)
+			}
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// GetUnknownFilters returns the unknown filters in the config set with the given sdk key.
+func GetUnknownFilters(sdkKey string) []UnknownFilterOccurrence {
+	config, err := getConfig(sdkKey)
+	if err != nil {
+		return nil
+	}
+	return config.unknownFilters
+}
+
+func collectUnknownFilters(f FilterOrOperator, filters []*UnknownFilter) []*UnknownFilter {
+	switch filter := f.(type) {
+	case *AudienceOperator:
+		if filter != nil {
+			for _, nested := range filter.Filters {
+				filters = collectUnknownFilters(nested, filters)
+			}
+		}
+	case AudienceOperator:
+		for _, nested := range filter.Filters {
+			filters = collectUnknownFilters(nested, filters)
+		}
+	case *UnknownFilter:
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// applyUnknownFilterPolicy records the unknown filters in the config and applies the policy to
+// them. Under UnknownFilterFailClosed, targets depending on an unknown filter directly or through
+// a referenced audience are recorded in unknownFilterTargets so they are never matched.
+func applyUnknownFilterPolicy(config *configBody, policy UnknownFilterPolicy) error {
+	audienceIds := make([]string, 0, len(config.Audiences))
+	for id := range config.Audiences {
+		audienceIds = append(audienceIds, id)
+	}
+	sort.Strings(audienceIds)
+
+	config.unknownFilterTargets = nil
+	var occurrences []UnknownFilterOccurrence
+	audienceOccurrences := make(map[string][]int)
+	var unknownFilters []*UnknownFilter
+	for _, id := range audienceIds {
+		filters := collectUnknownFilters(config.Audiences[id].Filters, nil)
+		for _, filter := range filters {
+			audienceOccurrences[id] = append(audienceOccurrences[id], len(occurrences))
+			occurrences = append(occurrences, UnknownFilterOccurrence{Path: fmt.Sprintf("audiences[%s]", id), Type: filter.Type})
+		}
+		unknownFilters = append(unknownFilters, filters...)
+	}
+
+	for _, feature := range config.Features {
+		affected := make(map[int]bool)
+		for _, target := range feature.Configuration.Targets {
+			if target.Audience == nil {
+				continue
+			}
+			filters := collectUnknownFilters(target.Audience.Filters, nil)
+			for _, filter := range filters {
+				affected[len(occurrences)] = true
+				occurrences = append(occurrences, UnknownFilterOccurrence{Path: fmt.Sprintf("features[%s].targets[%s]", feature.Key, target.Id), Type: filter.Type})
+			}
+			unknownFilters = append(unknownFilters, filters...)
+
+			dependsOnUnknownFilter := len(filters) > 0
+			references := collectAudienceReferences(target.Audience.Filters, nil)
+			visited := make(map[string]bool)
+			for len(references) > 0 {
+				id := references[len(references)-1]
+				references = references[:len(references)-1]
+				if visited[id] {
+					continue
+				}
+				visited[id] = true
+				for _, i := range audienceOccurrences[id] {
+					affected[i] = true
+					dependsOnUnknownFilter = true
+				}
+				if audience, ok := config.Audiences[id]; ok {
+					references = collectAudienceReferences(audience.Filters, references)
+				}
+			}
+			if dependsOnUnknownFilter {
+				if config.unknownFilterTargets == nil {
+					config.unknownFilterTargets = make(map[*Target]bool)
+				}
+				config.unknownFilterTargets[target] = true
+			}
+		}
+		for i := range affected {
+			occurrences[i].Features = append(occurrences[i].Features, feature.Key)
+		}
+	}
+	config.unknownFilters = occurrences
+
+	switch policy {
+	case UnknownFilterFailOpen:
+		for _, filter := range unknownFilters {
+			filter.pass = true
+		}
+		config.unknownFilterTargets = nil
+	case UnknownFilterReject:
+		for _, occurrence := range occurrences {
+			if len(occurrence.Features) > 0 {
+				return &UnknownFilterError{Occurrences: occurrences}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+// unknownFilterConfig builds a config JSON with features "direct", whose target matches an
+// unknown filter or everyone, "referenced", whose target matches an audience containing an
+// unknown filter, and "unaffected", which targets everyone.
+func unknownFilterConfig(t *testing.T) []byte {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{
+		{key: "direct", variation: "on"},
+		{key: "referenced", variation: "on"},
+		{key: "unaffected", variation: "on"},
+	}), &config))
+	unknown := map[string]interface{}{"type": "somethingNew"}
+	config["audiences"] = map[string]interface{}{
+		"with-unknown": map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{unknown}}},
+	}
+	features := config["features"].([]interface{})
+	target := func(i int) map[string]interface{} {
+		return features[i].(map[string]interface{})["configuration"].(map[string]interface{})["targets"].([]interface{})[0].(map[string]interface{})
+	}
+	target(0)["_audience"] = map[string]interface{}{"filters": map[string]interface{}{"operator": "or", "filters": []interface{}{
+		unknown,
+		map[string]interface{}{"type": "all"},
+	}}}
+	setTargetFilter(target(1), map[string]interface{}{"type": "audienceMatch", "comparator": "!=", "_audiences": []interface{}{"with-unknown"}})
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	return configJSON
+}
+
+func TestMixedFilters_UnknownFilterType(t *testing.T) {
+	var operator AudienceOperator
+	require.NoError(t, json.Unmarshal([]byte(`{"operator": "or", "filters": [{"type": "somethingNew"}, {"type": "optIn"}]}`), &operator))
+	require.Len(t, operator.Filters, 2)
+	require.Equal(t, &UnknownFilter{filter: filter{Type: "somethingNew"}}, operator.Filters[0])
+
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+	require.False(t, operator.Evaluate(nil, user, nil))
+}
+
+func TestSetConfig_UnknownFilterPolicy(t *testing.T) {
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+	testCases := []struct {
+		name           string
+		policy         UnknownFilterPolicy
+		expectedServed map[string]bool
+	}{
+		{
+			name:           "fail closed",
+			policy:         UnknownFilterFailClosed,
+			expectedServed: map[string]bool{"direct": false, "referenced": false, "unaffected": true},
+		},
+		{
+			name:           "fail open",
+			policy:         UnknownFilterFailOpen,
+			expectedServed: map[string]bool{"direct": true, "referenced": false, "unaffected": true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sdkKey := "dvc_server_unknown_filters_" + tc.name
+			SetUnknownFilterPolicy(sdkKey, tc.policy)
+			defer SetUnknownFilterPolicy(sdkKey, UnknownFilterFailClosed)
+			require.NoError(t, SetConfig(unknownFilterConfig(t), sdkKey, "", "", ""))
+
+			for featureKey, served := range tc.expectedServed {
+				_, _, _, _, _, err := VariableForUser(sdkKey, user, "var-"+featureKey, VariableTypesBool, nil, nil)
+				if served {
+					require.NoError(t, err, featureKey)
+				} else {
+					require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets, featureKey)
+				}
+			}
+			require.Equal(t, []UnknownFilterOccurrence{
+				{Path: "audiences[with-unknown]", Type: "somethingNew", Features: []string{"referenced"}},
+				{Path: "features[direct].targets[target-direct]", Type: "somethingNew", Features: []string{"direct"}},
+			}, GetUnknownFilters(sdkKey))
+		})
+	}
+}
+
+func TestSetConfig_UnknownFilterReject(t *testing.T) {
+	sdkKey := "dvc_server_unknown_filters_reject"
+	SetUnknownFilterPolicy(sdkKey, UnknownFilterReject)
+	defer SetUnknownFilterPolicy(sdkKey, UnknownFilterFailClosed)
+
+	err := SetConfig(unknownFilterConfig(t), sdkKey, "", "", "")
+	var unknownFilterErr *UnknownFilterError
+	require.ErrorAs(t, err, &unknownFilterErr)
+	require.Equal(t, []string{"direct", "referenced"}, unknownFilterErr.Features())
+	require.False(t, HasConfig(sdkKey))
+
+	// Unknown filters in audiences no target depends on are tolerated
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(prerequisiteConfig(t, []prerequisiteFeature{{key: "a", variation: "on"}}), &config))
+	config["audiences"] = map[string]interface{}{
+		"unused": map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{map[string]interface{}{"type": "somethingNew"}}}},
+	}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, SetConfig(configJSON, sdkKey, "", "", ""))
+	require.Len(t, GetUnknownFilters(sdkKey), 1)
+}
@@ -15,11 +15,13 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/devcyclehq/go-server-sdk/v2/util"
 
 	"github.com/devcyclehq/go-server-sdk/v2/api"
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
 	"github.com/matryer/try"
 )
 
@@ -57,6 +59,8 @@ type Client struct {
 	isClosed                   bool
 	internalClientEventChannel chan api.ClientEvent
 	evalHookRunner             *EvalHookRunner
+	// jsonSchemas holds the JSONSchema registered for each JSON variable key
+	jsonSchemas sync.Map
 }
 
 type LocalBucketing interface {
@@ -68,6 +72,37 @@ type LocalBucketing interface {
 	Close()
 }
 
+// QuarantineReporter is implemented by local bucketing that can report the features left out of
+// the current config because they are invalid.
+type QuarantineReporter interface {
+	QuarantinedFeatures() []QuarantinedFeature
+}
+
+// BucketingKeyFallbackReporter is implemented by local bucketing that can report how often users
+// were bucketed without a value for their target's bucketing key.
+type BucketingKeyFallbackReporter interface {
+	BucketingKeyFallbackCounts() []BucketingKeyFallbackCount
+}
+
+// ConfigCatalog is implemented by local bucketing that can describe the features and variables of
+// the current config.
+type ConfigCatalog interface {
+	Features() ([]FeatureDescriptor, error)
+	VariablesCatalog() ([]VariableDescriptor, error)
+}
+
+// validatingLocalBucketing is implemented by local bucketing that can validate a variable's value
+// before reporting its evaluation.
+type validatingLocalBucketing interface {
+	ValidatedVariable(user User, key string, variableType string, validate bucketing.ValueValidator) (variable Variable, metadata VariableMetadata, err error)
+}
+
+// LocalBucketingPreviewer is implemented by local bucketing that can preview variables at a given
+// time, without queueing events or storing sticky assignments.
+type LocalBucketingPreviewer interface {
+	VariableAt(user User, key string, variableType string, at time.Time) (variable Variable, metadata VariableMetadata, err error)
+}
+
 type SDKEvent struct {
 	Success             bool   `json:"success"`
 	Message             string `json:"message"`
@@ -277,6 +312,12 @@ Variable - Get variable by key for user data
     -@return Variable
 */
 func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (result Variable, err error) {
+	return c.variable(userdata, key, defaultValue, nil)
+}
+
+// variable evaluates the variable, defaulting values that fail validate before the evaluation is
+// reported to hooks and, with local bucketing, to events.
+func (c *Client) variable(userdata User, key string, defaultValue interface{}, validate bucketing.ValueValidator) (result Variable, err error) {
 	if key == "" {
 		return Variable{}, errors.New("invalid key provided for call to Variable")
 	}
@@ -321,7 +362,7 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 		}
 
 		var metadata VariableMetadata
-		variable, metadata, err = c.evaluateVariable(userdata, key, variableType, defaultValue, convertedDefaultValue, variable)
+		variable, metadata, err = c.evaluateVariable(userdata, key, variableType, defaultValue, convertedDefaultValue, variable, validate)
 
 		hookContext.VariableDetails = variable
 		if hookError == nil {
@@ -336,42 +377,88 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 			c.evalHookRunner.RunErrorHooks(hooks, hookContext, err)
 		}
 	} else {
-		variable, _, err := c.evaluateVariable(userdata, key, variableType, defaultValue, convertedDefaultValue, variable)
+		variable, _, err := c.evaluateVariable(userdata, key, variableType, defaultValue, convertedDefaultValue, variable, validate)
 		return variable, err
 	}
 
 	return variable, nil
 }
 
-func (c *Client) evaluateVariable(userdata User, key string, variableType string, defaultValue interface{}, convertedDefaultValue interface{}, variable Variable) (Variable, VariableMetadata, error) {
-	// Perform variable evaluation
-	if c.IsLocalBucketing() {
-		bucketedVariable, metadata, err := c.localBucketing.Variable(userdata, key, variableType)
+/*
+EvaluateAt - Preview the evaluation of a variable for user data at a given time, such as the state of a
+scheduled rollout tomorrow. No events are sent and evaluation hooks are not run. Only supported with
+local bucketing.
+
+  - @param key Variable key
+
+  - @param defaultValue Default value
+
+  - @param at Time to evaluate the variable at
+
+    -@return Variable
+*/
+func (c *Client) EvaluateAt(userdata User, key string, defaultValue interface{}, at time.Time) (Variable, error) {
+	previewer, ok := c.localBucketing.(LocalBucketingPreviewer)
+	if !c.IsLocalBucketing() || !ok {
+		return Variable{}, errors.New("EvaluateAt is only supported with local bucketing")
+	}
+	if key == "" {
+		return Variable{}, errors.New("invalid key provided for call to EvaluateAt")
+	}
+
+	convertedDefaultValue := convertDefaultValueType(defaultValue)
+	variableType, err := variableTypeFromValue(key, convertedDefaultValue, true)
+	if err != nil {
+		return Variable{}, err
+	}
+
+	baseVar := BaseVariable{Key: key, Value: convertedDefaultValue, Type_: variableType, Eval: api.EvalDetails{
+		Reason:  api.EvaluationReasonDefault,
+		Details: string(api.DefaultReasonError),
+	}}
+	variable := Variable{BaseVariable: baseVar, DefaultValue: convertedDefaultValue, IsDefaulted: true}
 
-		sameTypeAsDefault := compareTypes(bucketedVariable.Value, convertedDefaultValue)
-		// if we have a value from the bucketed config and its the same type as the default value or the default value is nil, we can use the value
-		if bucketedVariable.Value != nil && (sameTypeAsDefault || defaultValue == nil) {
-			variable.Type_ = bucketedVariable.Type_
-			variable.Value = bucketedVariable.Value
-			variable.IsDefaulted = false
-			variable.Eval = bucketedVariable.Eval
+	bucketedVariable, _, err := previewer.VariableAt(userdata, key, variableType, at)
+	return resolveBucketedVariable(key, defaultValue, convertedDefaultValue, bucketedVariable, variable), err
+}
+
+// resolveBucketedVariable returns the variable with the value from local bucketing, or defaulted if
+// the bucketed value is missing or doesn't match the type of the default value.
+func resolveBucketedVariable(key string, defaultValue interface{}, convertedDefaultValue interface{}, bucketedVariable Variable, variable Variable) Variable {
+	sameTypeAsDefault := compareTypes(bucketedVariable.Value, convertedDefaultValue)
+	// if we have a value from the bucketed config and its the same type as the default value or the default value is nil, we can use the value
+	if bucketedVariable.Value != nil && (sameTypeAsDefault || defaultValue == nil) {
+		variable.Type_ = bucketedVariable.Type_
+		variable.Value = bucketedVariable.Value
+		variable.IsDefaulted = false
+		variable.Eval = bucketedVariable.Eval
+	} else {
+		// if the value is not the same type as the default value, we need to return an error
+		if !sameTypeAsDefault && bucketedVariable.Value != nil {
+			util.Warnf("Type mismatch for variable %s. Expected type %s, got %s",
+				key,
+				reflect.TypeOf(defaultValue).String(),
+				reflect.TypeOf(bucketedVariable.Value).String(),
+			)
+			variable.Eval.Details = string(api.DefaultReasonInvalidVariableType)
 		} else {
-			// if the value is not the same type as the default value, we need to return an error
-			if !sameTypeAsDefault && bucketedVariable.Value != nil {
-				util.Warnf("Type mismatch for variable %s. Expected type %s, got %s",
-					key,
-					reflect.TypeOf(defaultValue).String(),
-					reflect.TypeOf(bucketedVariable.Value).String(),
-				)
-				variable.Eval.Details = string(api.DefaultReasonInvalidVariableType)
-			} else {
-				// default the variable to the default value
-				variable.Eval.Details = bucketedVariable.Eval.Details
-				variable.Eval.Reason = api.EvaluationReasonDefault
-			}
+			// default the variable to the default value
+			variable.Eval.Details = bucketedVariable.Eval.Details
+			variable.Eval.Reason = api.EvaluationReasonDefault
 		}
+	}
+	return variable
+}
 
-		return variable, metadata, err
+func (c *Client) evaluateVariable(userdata User, key string, variableType string, defaultValue interface{}, convertedDefaultValue interface{}, variable Variable, validate bucketing.ValueValidator) (Variable, VariableMetadata, error) {
+	// Perform variable evaluation
+	if c.IsLocalBucketing() {
+		if validator, ok := c.localBucketing.(validatingLocalBucketing); ok && validate != nil {
+			bucketedVariable, metadata, err := validator.ValidatedVariable(userdata, key, variableType, validate)
+			return resolveBucketedVariable(key, defaultValue, convertedDefaultValue, bucketedVariable, variable), metadata, err
+		}
+		bucketedVariable, metadata, err := c.localBucketing.Variable(userdata, key, variableType)
+		return validateVariable(key, resolveBucketedVariable(key, defaultValue, convertedDefaultValue, bucketedVariable, variable), variable, validate), metadata, err
 	}
 
 	populatedUser := userdata.GetPopulatedUser(c.platformData)
@@ -403,10 +490,13 @@ func (c *Client) evaluateVariable(userdata User, key string, variableType string
 		err = decode(&localVarReturnValue, body, r.Header.Get("Content-Type"))
 		if err == nil && localVarReturnValue.Value != nil {
 			if compareTypes(localVarReturnValue.Value, convertedDefaultValue) {
+				defaultVariable := variable
 				variable.Value = localVarReturnValue.Value
 				variable.IsDefaulted = false
 				variable.Eval.Reason = api.EvaluationReasonTargetingMatch
 				variable.Eval.Details = ""
+				// The evaluation is reported by the cloud before it can be validated
+				variable = validateVariable(key, variable, defaultVariable, validate)
 			} else {
 				variable.Eval.Reason = api.EvaluationReasonDefault
 				variable.Eval.Details = string(api.DefaultReasonVariableTypeMismatch)
@@ -432,6 +522,23 @@ func (c *Client) evaluateVariable(userdata User, key string, variableType string
 	return variable, metadata, nil
 }
 
+// validateVariable returns the default variable with the "Invalid JSON Value" default reason if
+// the variable's value fails validate.
+func validateVariable(key string, variable Variable, defaultVariable Variable, validate bucketing.ValueValidator) Variable {
+	if validate == nil || variable.IsDefaulted {
+		return variable
+	}
+	if err := validate(variable.Value); err != nil {
+		util.Warnf("Defaulting variable %s: %s", key, err)
+		defaultVariable.Eval = api.EvalDetails{
+			Reason:  api.EvaluationReasonDefault,
+			Details: string(api.DefaultReasonInvalidJSONValue),
+		}
+		return defaultVariable
+	}
+	return variable
+}
+
 func (c *Client) AllVariables(user User) (map[string]ReadOnlyVariable, error) {
 	var (
 		httpMethod          = strings.ToUpper("Post")
@@ -492,7 +599,7 @@ func (c *Client) Track(user User, event Event) (bool, error) {
 		return false, errors.New("event type is required")
 	}
 	if event.ClientDate.IsZero() || event.ClientDate.Before(time.UnixMilli(0)) {
-		event.ClientDate = time.Now()
+		event.ClientDate = c.DevCycleOptions.clock().Now()
 	}
 	if c.IsLocalBucketing() {
 		if c.hasConfig() {
@@ -625,6 +732,73 @@ func (c *Client) GetMetadata() (ConfigMetadata, error) {
 	}, nil
 }
 
+// QuarantinedFeatures returns the features left out of the current config because they are
+// invalid, when Options.QuarantineInvalidFeatures is set.
+// Returns error for cloud SDK or when config is not available
+func (c *Client) QuarantinedFeatures() ([]QuarantinedFeature, error) {
+	if !c.IsLocalBucketing() {
+		return nil, fmt.Errorf("quarantined features not available for cloud SDK")
+	}
+	reporter, ok := c.localBucketing.(QuarantineReporter)
+	if !ok {
+		return nil, fmt.Errorf("quarantined features not available for this local bucketing")
+	}
+	if !c.hasConfig() {
+		return nil, fmt.Errorf("quarantined features not available - config not loaded")
+	}
+	return reporter.QuarantinedFeatures(), nil
+}
+
+// BucketingKeyFallbackCounts returns how often users were bucketed into each target without a
+// value for its bucketing key, since the current config was loaded.
+// Returns error for cloud SDK or when config is not available
+func (c *Client) BucketingKeyFallbackCounts() ([]BucketingKeyFallbackCount, error) {
+	if !c.IsLocalBucketing() {
+		return nil, fmt.Errorf("bucketing key fallback counts not available for cloud SDK")
+	}
+	reporter, ok := c.localBucketing.(BucketingKeyFallbackReporter)
+	if !ok {
+		return nil, fmt.Errorf("bucketing key fallback counts not available for this local bucketing")
+	}
+	if !c.hasConfig() {
+		return nil, fmt.Errorf("bucketing key fallback counts not available - config not loaded")
+	}
+	return reporter.BucketingKeyFallbackCounts(), nil
+}
+
+// Features describes the features of the current config, ordered by key.
+// Returns error for cloud SDK or when config is not available
+func (c *Client) Features() ([]FeatureDescriptor, error) {
+	if !c.IsLocalBucketing() {
+		return nil, fmt.Errorf("features not available for cloud SDK")
+	}
+	catalog, ok := c.localBucketing.(ConfigCatalog)
+	if !ok {
+		return nil, fmt.Errorf("features not available for this local bucketing")
+	}
+	if !c.hasConfig() {
+		return nil, fmt.Errorf("features not available - config not loaded")
+	}
+	return catalog.Features()
+}
+
+// VariablesCatalog describes the variables of the current config and the features that set them,
+// ordered by key.
+// Returns error for cloud SDK or when config is not available
+func (c *Client) VariablesCatalog() ([]VariableDescriptor, error) {
+	if !c.IsLocalBucketing() {
+		return nil, fmt.Errorf("variables catalog not available for cloud SDK")
+	}
+	catalog, ok := c.localBucketing.(ConfigCatalog)
+	if !ok {
+		return nil, fmt.Errorf("variables catalog not available for this local bucketing")
+	}
+	if !c.hasConfig() {
+		return nil, fmt.Errorf("variables catalog not available - config not loaded")
+	}
+	return catalog.VariablesCatalog()
+}
+
 func (c *Client) hasConfig() bool {
 	return c.configManager.HasConfig()
 }
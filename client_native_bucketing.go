@@ -46,6 +46,10 @@ func NewNativeLocalBucketing(sdkKey string, platformData *api.PlatformData, opti
 	if err != nil {
 		return nil, err
 	}
+	bucketing.SetClock(sdkKey, options.clock())
+	bucketing.SetAssignmentStore(sdkKey, options.AssignmentStore)
+	bucketing.SetUnknownFilterPolicy(sdkKey, options.UnknownFilterPolicy)
+	bucketing.SetQuarantineInvalidFeatures(sdkKey, options.QuarantineInvalidFeatures)
 	return &NativeLocalBucketing{
 		sdkKey:       sdkKey,
 		options:      options,
@@ -83,6 +87,26 @@ func (n *NativeLocalBucketing) GetLastModified() string {
 	return bucketing.GetLastModified(n.sdkKey)
 }
 
+func (n *NativeLocalBucketing) UnknownFilters() []UnknownFilterOccurrence {
+	return bucketing.GetUnknownFilters(n.sdkKey)
+}
+
+func (n *NativeLocalBucketing) QuarantinedFeatures() []QuarantinedFeature {
+	return bucketing.GetQuarantinedFeatures(n.sdkKey)
+}
+
+func (n *NativeLocalBucketing) BucketingKeyFallbackCounts() []BucketingKeyFallbackCount {
+	return bucketing.GetBucketingKeyFallbackCounts(n.sdkKey)
+}
+
+func (n *NativeLocalBucketing) Features() ([]FeatureDescriptor, error) {
+	return bucketing.GetFeatures(n.sdkKey)
+}
+
+func (n *NativeLocalBucketing) VariablesCatalog() ([]VariableDescriptor, error) {
+	return bucketing.GetVariablesCatalog(n.sdkKey)
+}
+
 func (n *NativeLocalBucketing) GenerateBucketedConfigForUser(user User) (ret *BucketedUserConfig, err error) {
 	populatedUser := user.GetPopulatedUserWithTime(n.platformData, DEFAULT_USER_TIME)
 	clientCustomData := bucketing.GetClientCustomData(n.sdkKey)
@@ -95,7 +119,31 @@ func (n *NativeLocalBucketing) SetClientCustomData(customData map[string]interfa
 }
 
 func (n *NativeLocalBucketing) Variable(user User, variableKey string, variableType string) (Variable, VariableMetadata, error) {
+	return n.variable(user, variableKey, variableType, func(populatedUser api.PopulatedUser, clientCustomData map[string]interface{}) (string, any, string, api.EvaluationReason, string, error) {
+		return bucketing.VariableForUser(n.sdkKey, populatedUser, variableKey, variableType, n.eventQueue, clientCustomData)
+	})
+}
+
+// ValidatedVariable is Variable with the variable's value checked by validate before its
+// evaluation is reported. Values that fail validation are defaulted.
+func (n *NativeLocalBucketing) ValidatedVariable(user User, variableKey string, variableType string, validate bucketing.ValueValidator) (Variable, VariableMetadata, error) {
+	return n.variable(user, variableKey, variableType, func(populatedUser api.PopulatedUser, clientCustomData map[string]interface{}) (string, any, string, api.EvaluationReason, string, error) {
+		return bucketing.ValidatedVariableForUser(n.sdkKey, populatedUser, variableKey, variableType, n.eventQueue, clientCustomData, validate)
+	})
+}
+
+// VariableAt previews the variable as of the given time without queueing any events or storing
+// sticky assignments.
+func (n *NativeLocalBucketing) VariableAt(user User, variableKey string, variableType string, at time.Time) (Variable, VariableMetadata, error) {
+	return n.variable(user, variableKey, variableType, func(populatedUser api.PopulatedUser, clientCustomData map[string]interface{}) (string, any, string, api.EvaluationReason, string, error) {
+		return bucketing.VariableForUserAt(n.sdkKey, populatedUser, variableKey, variableType, nil, clientCustomData, at)
+	})
+}
+
+// bucketedVariableFunc evaluates a variable for a user with native bucketing.
+type bucketedVariableFunc func(user api.PopulatedUser, clientCustomData map[string]interface{}) (variableType string, variableValue any, featureId string, evalReason api.EvaluationReason, evalDetails string, err error)
 
+func (n *NativeLocalBucketing) variable(user User, variableKey string, variableType string, evaluate bucketedVariableFunc) (Variable, VariableMetadata, error) {
 	defaultVar := Variable{
 		BaseVariable: api.BaseVariable{
 			Key:   variableKey,
@@ -111,7 +159,7 @@ func (n *NativeLocalBucketing) Variable(user User, variableKey string, variableT
 	}
 	clientCustomData := bucketing.GetClientCustomData(n.sdkKey)
 	populatedUser := user.GetPopulatedUserWithTime(n.platformData, DEFAULT_USER_TIME)
-	resultVariableType, resultValue, featureId, evalReason, evalDetails, err := bucketing.VariableForUser(n.sdkKey, populatedUser, variableKey, variableType, n.eventQueue, clientCustomData)
+	resultVariableType, resultValue, featureId, evalReason, evalDetails, err := evaluate(populatedUser, clientCustomData)
 	metadata := VariableMetadata{}
 
 	if err != nil {
@@ -143,6 +191,8 @@ func (n *NativeLocalBucketing) Close() {
 	if err != nil {
 		util.Errorf("Error closing event queue: %v", err)
 	}
+	bucketing.SetClock(n.sdkKey, nil)
+	bucketing.SetAssignmentStore(n.sdkKey, nil)
 }
 
 func (n *NativeLocalBucketing) QueueEvent(user User, event Event) error {
@@ -1,6 +1,7 @@
 package devcycle
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -41,6 +42,29 @@ func TestClient_AllVariablesLocal(t *testing.T) {
 	require.Len(t, variables, 5)
 }
 
+func TestClient_FeaturesAndVariablesCatalog_Local(t *testing.T) {
+	sdkKey, _ := httpConfigMock(200)
+	c, err := NewClient(sdkKey, &Options{})
+	require.NoError(t, err)
+
+	features, err := c.Features()
+	require.NoError(t, err)
+	require.NotEmpty(t, features)
+	featureKeys := make(map[string]bool)
+	for _, feature := range features {
+		featureKeys[feature.Key] = true
+	}
+
+	variables, err := c.VariablesCatalog()
+	require.NoError(t, err)
+	require.NotEmpty(t, variables)
+	for _, variable := range variables {
+		if variable.FeatureKey != "" {
+			require.True(t, featureKeys[variable.FeatureKey], variable.Key)
+		}
+	}
+}
+
 func TestClient_AllVariablesLocal_WithSpecialCharacters(t *testing.T) {
 	sdkKey := generateTestSDKKey()
 	httpCustomConfigMock(sdkKey, 200, test_config_special_characters_var, false)
@@ -215,6 +239,29 @@ func TestClient_VariableLocal(t *testing.T) {
 	}
 }
 
+func TestClient_EvaluateAt(t *testing.T) {
+	sdkKey, _ := httpConfigMock(200)
+	c, err := NewClient(sdkKey, &Options{})
+	require.NoError(t, err)
+	user := User{UserId: "j_test", DeviceModel: "testing"}
+
+	variable, err := c.Variable(user, "test", true)
+	require.NoError(t, err)
+	preview, err := c.EvaluateAt(user, "test", true, time.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, variable, preview)
+
+	preview, err = c.EvaluateAt(user, "missing-variable", "default", time.Now())
+	require.NoError(t, err)
+	require.True(t, preview.IsDefaulted)
+	require.Equal(t, "default", preview.Value)
+
+	cloudClient, err := NewClient(generateTestSDKKey(), &Options{EnableCloudBucketing: true, ConfigPollingIntervalMS: 10 * time.Second})
+	require.NoError(t, err)
+	_, err = cloudClient.EvaluateAt(user, "test", true, time.Now())
+	require.Error(t, err)
+}
+
 func TestClient_VariableLocal_UserWithCustomData(t *testing.T) {
 	sdkKey, _ := httpConfigMock(200)
 
@@ -355,6 +402,35 @@ func TestClient_CloudBucketingHandler(t *testing.T) {
 	}
 }
 
+func TestClient_UnknownFiltersEvent(t *testing.T) {
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(test_config), &config))
+	config["audiences"] = map[string]interface{}{
+		"unknown": map[string]interface{}{"filters": map[string]interface{}{"operator": "and", "filters": []interface{}{map[string]interface{}{"type": "somethingNew"}}}},
+	}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+	sdkKey := generateTestSDKKey()
+	httpCustomConfigMock(sdkKey, 200, string(configJSON), false)
+
+	clientEventHandler := make(chan api.ClientEvent, 10)
+	c, err := NewClient(sdkKey, &Options{ClientEventHandler: clientEventHandler})
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+	for {
+		select {
+		case event := <-clientEventHandler:
+			if event.EventType != api.ClientEventType_UnknownFilters {
+				continue
+			}
+			require.Equal(t, []UnknownFilterOccurrence{{Path: "audiences[unknown]", Type: "somethingNew"}}, event.EventData)
+			return
+		case <-time.After(time.Second):
+			t.Fatal("Expected an unknown filters event")
+		}
+	}
+}
+
 func TestClient_LocalBucketingHandler(t *testing.T) {
 
 	sdkKey, _ := httpConfigMock(200)
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+const sdkKey = "dvc_server_eval"
+
+type result struct {
+	Key       string               `json:"key"`
+	Type      string               `json:"type,omitempty"`
+	Value     interface{}          `json:"value"`
+	Feature   string               `json:"feature,omitempty"`
+	Variation string               `json:"variation,omitempty"`
+	Reason    api.EvaluationReason `json:"reason"`
+	// Details is the default reason for defaulted variables
+	Details string                  `json:"details,omitempty"`
+	Trace   []bucketing.TargetTrace `json:"trace,omitempty"`
+}
+
+// configVariables is the part of a config needed to find each variable's feature.
+type configVariables struct {
+	Features []struct {
+		Id         string `json:"_id"`
+		Key        string `json:"key"`
+		Variations []struct {
+			Variables []struct {
+				Var string `json:"_var"`
+			} `json:"variables"`
+		} `json:"variations"`
+	} `json:"features"`
+	Variables []struct {
+		Id  string `json:"_id"`
+		Key string `json:"key"`
+	} `json:"variables"`
+}
+
+// evaluate evaluates the variable with the given key for the user, or every variable in the
+// config if the key is empty.
+func evaluate(configJSON []byte, user api.User, variableKey string, trace bool, at time.Time) ([]result, error) {
+	if err := bucketing.SetConfig(configJSON, sdkKey, "", "", ""); err != nil {
+		return nil, err
+	}
+	var config configVariables
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, err
+	}
+	variableFeatures := make(map[string]string)
+	for _, feature := range config.Features {
+		for _, variation := range feature.Variations {
+			for _, variable := range variation.Variables {
+				variableFeatures[variable.Var] = feature.Id
+			}
+		}
+	}
+	featureIds := make(map[string]string)
+	for _, variable := range config.Variables {
+		featureIds[variable.Key] = variableFeatures[variable.Id]
+	}
+
+	keys := []string{variableKey}
+	if variableKey == "" {
+		keys = keys[:0]
+		for key := range featureIds {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	} else if _, ok := featureIds[variableKey]; !ok {
+		return nil, fmt.Errorf("variable %s is not in the config", variableKey)
+	}
+
+	populatedUser := user.GetPopulatedUser((&api.PlatformData{}).Default())
+	evaluate := bucketing.EvaluateFeatures
+	if trace {
+		evaluate = bucketing.EvaluateFeaturesWithTrace
+	}
+	evaluations, err := evaluate(sdkKey, populatedUser, nil, at)
+	if err != nil {
+		return nil, err
+	}
+	featureEvaluations := make(map[string]bucketing.FeatureEvaluation, len(evaluations))
+	for _, evaluation := range evaluations {
+		featureEvaluations[evaluation.FeatureId] = evaluation
+	}
+
+	results := make([]result, 0, len(keys))
+	for _, key := range keys {
+		variableType, value, _, reason, details, err := bucketing.VariableForUserAt(sdkKey, populatedUser, key, "", nil, nil, at)
+		r := result{Key: key, Type: variableType, Value: value, Reason: reason}
+		if err != nil {
+			r.Details = details
+		}
+		if evaluation, ok := featureEvaluations[featureIds[key]]; ok {
+			r.Feature = evaluation.FeatureKey
+			r.Variation = evaluation.VariationKey
+			r.Trace = evaluation.Trace
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func writeText(w io.Writer, results []result) {
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "variable %s\n", r.Key)
+		if r.Details != "" {
+			fmt.Fprintf(w, "  reason: %s (%s)\n", r.Reason, r.Details)
+		} else {
+			value, _ := json.Marshal(r.Value)
+			fmt.Fprintf(w, "  value: %s (%s)\n", value, r.Type)
+			fmt.Fprintf(w, "  reason: %s\n", r.Reason)
+		}
+		if r.Feature != "" {
+			fmt.Fprintf(w, "  feature: %s\n", r.Feature)
+		}
+		if r.Variation != "" {
+			fmt.Fprintf(w, "  variation: %s\n", r.Variation)
+		}
+		for _, target := range r.Trace {
+			fmt.Fprintf(w, "  target %s: %s\n", target.TargetId, describeTarget(target))
+		}
+	}
+}
+
+func describeTarget(target bucketing.TargetTrace) string {
+	if !target.Active {
+		return "inactive"
+	}
+	description := ""
+	if rollout := target.Rollout; rollout != nil {
+		inRollout := "not in rollout"
+		if rollout.InRollout {
+			inRollout = "in rollout"
+		}
+		description = fmt.Sprintf("rollout %.2f%%, hash %.4f, %s", 100*rollout.Percentage, rollout.Hash, inRollout)
+		if !rollout.InRollout {
+			return description
+		}
+		description += ", "
+	}
+	if target.AudienceMatched {
+		return description + "audience matched"
+	}
+	return description + "audience not matched"
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+func TestEvaluate(t *testing.T) {
+	configJSON, err := os.ReadFile("../../bucketing/testdata/fixture_test_config.json")
+	require.NoError(t, err)
+	user := api.User{UserId: "asuh", Email: "test@email.com", Country: "Canada"}
+
+	results, err := evaluate(configJSON, user, "audience-match", true, time.Now())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "audience_match", results[0].Value)
+	require.Equal(t, "feature3", results[0].Feature)
+	require.Equal(t, "audience-match-variation", results[0].Variation)
+	require.Equal(t, api.EvaluationReasonTargetingMatch, results[0].Reason)
+	require.NotEmpty(t, results[0].Trace)
+
+	results, err = evaluate(configJSON, api.User{UserId: "someone"}, "", false, time.Now())
+	require.NoError(t, err)
+	require.Greater(t, len(results), 1)
+	for _, r := range results {
+		require.Nil(t, r.Trace)
+		if r.Key == "audience-match" {
+			require.Equal(t, api.EvaluationReasonDefault, r.Reason)
+			require.Equal(t, string(api.DefaultReasonUserNotTargeted), r.Details)
+		}
+	}
+
+	var out bytes.Buffer
+	writeText(&out, results)
+	require.Contains(t, out.String(), "variable audience-match\n  reason: DEFAULT (User Not Targeted)")
+
+	_, err = evaluate(configJSON, user, "missing", false, time.Now())
+	require.Error(t, err)
+}
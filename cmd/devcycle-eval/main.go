@@ -0,0 +1,78 @@
+// Command devcycle-eval evaluates variables for a user against a config, using the same bucketing
+// code as the SDK, and prints each variable's value, variation and evaluation reason.
+//
+// Usage:
+//
+//	devcycle-eval -config config.json -user '{"user_id": "user"}' [-variable key] [-trace]
+//
+// The config is read from stdin if -config is "-" or unset. Every variable in the config is
+// evaluated unless -variable is set, and -trace adds how each of the feature's targets was
+// evaluated.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+func main() {
+	configPath := flag.String("config", "-", "path to the config JSON file, or - for stdin")
+	userJSON := flag.String("user", "", "user to evaluate, as JSON")
+	variableKey := flag.String("variable", "", "variable to evaluate (default all variables)")
+	trace := flag.Bool("trace", false, "include the targeting trace")
+	at := flag.String("at", "", "RFC 3339 time to evaluate at (default now)")
+	jsonOutput := flag.Bool("json", false, "print the results as JSON")
+	flag.Parse()
+
+	if err := run(*configPath, *userJSON, *variableKey, *trace, *at, *jsonOutput); err != nil {
+		fmt.Fprintf(os.Stderr, "devcycle-eval: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, userJSON, variableKey string, trace bool, at string, jsonOutput bool) error {
+	if userJSON == "" {
+		flag.Usage()
+		return fmt.Errorf("-user is required")
+	}
+	var user api.User
+	if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
+		return fmt.Errorf("invalid -user: %w", err)
+	}
+	evaluatedAt := time.Now()
+	if at != "" {
+		var err error
+		if evaluatedAt, err = time.Parse(time.RFC3339, at); err != nil {
+			return fmt.Errorf("invalid -at: %w", err)
+		}
+	}
+
+	var configJSON []byte
+	var err error
+	if configPath == "" || configPath == "-" {
+		configJSON, err = io.ReadAll(os.Stdin)
+	} else {
+		configJSON, err = os.ReadFile(configPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	results, err := evaluate(configJSON, user, variableKey, trace, evaluatedAt)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	writeText(os.Stdout, results)
+	return nil
+}
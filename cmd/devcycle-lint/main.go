@@ -0,0 +1,88 @@
+// Command devcycle-lint reports semantic problems in a config, such as target distributions that
+// don't sum to 1 or variation values that don't match their variable's type.
+//
+// Usage:
+//
+//	devcycle-lint [-json] [-strict] [-unknown-filters closed|open|reject] [config.json]
+//
+// The config is read from stdin if no file is given. The exit status is 1 if any errors are
+// found, or any problems at all with -strict. Unknown filters are reported as evaluated under the
+// -unknown-filters policy the SDK is configured with.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "print the problems as JSON")
+	strict := flag.Bool("strict", false, "fail on warnings as well as errors")
+	unknownFilters := flag.String("unknown-filters", "closed", "unknown filter policy: closed, open or reject")
+	flag.Parse()
+
+	policy, ok := unknownFilterPolicies[*unknownFilters]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "devcycle-lint: invalid -unknown-filters %q\n", *unknownFilters)
+		os.Exit(2)
+	}
+	failed, err := run(flag.Arg(0), *jsonOutput, *strict, policy, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "devcycle-lint: %s\n", err)
+		os.Exit(2)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+var unknownFilterPolicies = map[string]bucketing.UnknownFilterPolicy{
+	"closed": bucketing.UnknownFilterFailClosed,
+	"open":   bucketing.UnknownFilterFailOpen,
+	"reject": bucketing.UnknownFilterReject,
+}
+
+// run lints the config at path and reports whether the lint failed.
+func run(path string, jsonOutput, strict bool, policy bucketing.UnknownFilterPolicy, w io.Writer) (bool, error) {
+	var rawConfig []byte
+	var err error
+	if path == "" || path == "-" {
+		rawConfig, err = io.ReadAll(os.Stdin)
+	} else {
+		rawConfig, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return false, err
+	}
+	problems, err := bucketing.LintWithPolicy(rawConfig, policy)
+	if err != nil {
+		return false, err
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if problems == nil {
+			problems = []bucketing.LintProblem{}
+		}
+		if err = encoder.Encode(problems); err != nil {
+			return false, err
+		}
+	} else {
+		for _, problem := range problems {
+			fmt.Fprintln(w, problem)
+		}
+	}
+
+	for _, problem := range problems {
+		if strict || problem.Severity == bucketing.LintSeverityError {
+			return true, nil
+		}
+	}
+	return false, nil
+}
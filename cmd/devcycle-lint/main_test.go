@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+func TestRun(t *testing.T) {
+	configPath := "../../testdata/fixture_large_config.json"
+
+	var out bytes.Buffer
+	failed, err := run(configPath, false, false, bucketing.UnknownFilterFailClosed, &out)
+	require.NoError(t, err)
+	require.False(t, failed, "warnings don't fail the lint")
+	require.Contains(t, out.String(), "warning: variables[v-key-16]: is not used by any feature")
+
+	failed, err = run(configPath, true, true, bucketing.UnknownFilterFailClosed, &out)
+	require.NoError(t, err)
+	require.True(t, failed, "warnings fail a strict lint")
+
+	invalidPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not json"), 0o600))
+	_, err = run(invalidPath, false, false, bucketing.UnknownFilterFailClosed, &out)
+	require.Error(t, err)
+}
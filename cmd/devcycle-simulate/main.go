@@ -0,0 +1,93 @@
+// Command devcycle-simulate buckets a population of users into a config and reports how they are
+// distributed across every feature's targets and variations, so that a targeting change can be
+// checked against a sample of real traffic before it is published.
+//
+// Usage:
+//
+//	devcycle-simulate -config config.json -users users.ndjson
+//
+// Users are read as newline delimited JSON objects in the same format as devcycle.User, or as CSV
+// with a header row. CSV columns named after devcycle.User fields (user_id, email, country, ...)
+// set those fields, and every other column is added to the user's custom data.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+const sdkKey = "dvc_server_simulate"
+
+func main() {
+	configPath := flag.String("config", "", "path to the config JSON file")
+	usersPath := flag.String("users", "", "path to the user population")
+	format := flag.String("format", "", "user population format, ndjson or csv (default from the file extension)")
+	at := flag.String("at", "", "RFC 3339 time to evaluate schedules at (default now)")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if err := run(*configPath, *usersPath, *format, *at, *jsonOutput); err != nil {
+		fmt.Fprintf(os.Stderr, "devcycle-simulate: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, usersPath, format, at string, jsonOutput bool) error {
+	if configPath == "" || usersPath == "" {
+		flag.Usage()
+		return fmt.Errorf("-config and -users are required")
+	}
+	evaluatedAt := time.Now()
+	if at != "" {
+		var err error
+		if evaluatedAt, err = time.Parse(time.RFC3339, at); err != nil {
+			return fmt.Errorf("invalid -at: %w", err)
+		}
+	}
+	if format == "" {
+		format = formatNDJSON
+		if strings.EqualFold(filepath.Ext(usersPath), ".csv") {
+			format = formatCSV
+		}
+	}
+
+	configJSON, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	if err = bucketing.SetConfig(configJSON, sdkKey, "", "", ""); err != nil {
+		return err
+	}
+	users, err := os.Open(usersPath)
+	if err != nil {
+		return err
+	}
+	defer users.Close()
+
+	platformData := (&api.PlatformData{}).Default()
+	report := newReport()
+	err = forEachUser(users, format, func(user api.User) error {
+		evaluations, err := bucketing.EvaluateFeatures(sdkKey, user.GetPopulatedUser(platformData), nil, evaluatedAt)
+		if err != nil {
+			return err
+		}
+		report.add(evaluations)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return report.writeJSON(os.Stdout)
+	}
+	report.writeText(os.Stdout)
+	return nil
+}
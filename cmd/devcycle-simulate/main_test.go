@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+func TestForEachUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []api.User
+	}{
+		{
+			name:   "ndjson",
+			format: formatNDJSON,
+			input:  "{\"user_id\": \"a\", \"country\": \"CA\"}\n{\"user_id\": \"b\", \"customData\": {\"plan\": \"pro\"}}\n",
+			want: []api.User{
+				{UserId: "a", Country: "CA"},
+				{UserId: "b", CustomData: map[string]interface{}{"plan": "pro"}},
+			},
+		},
+		{
+			name:   "csv",
+			format: formatCSV,
+			input:  "user_id,country,plan,seats,beta\na,CA,,,\nb,,pro,5,true\n",
+			want: []api.User{
+				{UserId: "a", Country: "CA"},
+				{UserId: "b", CustomData: map[string]interface{}{"plan": "pro", "seats": 5.0, "beta": true}},
+			},
+		},
+		{
+			name:   "csv numbers and booleans",
+			format: formatCSV,
+			input:  "user_id,a,b,c,d\na,1,0,false,T\n",
+			want: []api.User{
+				{UserId: "a", CustomData: map[string]interface{}{"a": 1.0, "b": 0.0, "c": false, "d": "T"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var users []api.User
+			err := forEachUser(strings.NewReader(test.input), test.format, func(user api.User) error {
+				users = append(users, user)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.want, users)
+		})
+	}
+
+	require.Error(t, forEachUser(strings.NewReader(""), "xml", nil))
+}
+
+func TestReport(t *testing.T) {
+	report := newReport()
+	report.add([]bucketing.FeatureEvaluation{
+		{FeatureKey: "feature", TargetId: "target", VariationKey: "on", IsRollout: true},
+	})
+	report.add([]bucketing.FeatureEvaluation{
+		{FeatureKey: "feature", DefaultReason: api.DefaultReasonUserNotInRollout},
+	})
+
+	require.Equal(t, 2, report.Users)
+	require.Len(t, report.Features, 1)
+	feature := report.Features[0]
+	require.Equal(t, 1, feature.Served)
+	require.Equal(t, 1, feature.Rollout)
+	require.Equal(t, map[string]int{"target": 1}, feature.Targets)
+	require.Equal(t, map[string]int{"on": 1}, feature.Variations)
+	require.Equal(t, map[string]int{string(api.DefaultReasonUserNotInRollout): 1}, feature.DefaultReasons)
+
+	var out bytes.Buffer
+	report.writeText(&out)
+	require.Contains(t, out.String(), "served: 1 (50.00%)")
+	require.Contains(t, out.String(), "variation on: 1 (50.00%)")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+type report struct {
+	Users    int              `json:"users"`
+	Features []*featureReport `json:"features"`
+
+	featuresByKey map[string]*featureReport
+}
+
+type featureReport struct {
+	Key string `json:"key"`
+	// Served is the number of users served a variation of the feature
+	Served int `json:"served"`
+	// Rollout is the number of users served through a target's rollout
+	Rollout        int            `json:"rollout"`
+	Targets        map[string]int `json:"targets"`
+	Variations     map[string]int `json:"variations"`
+	DefaultReasons map[string]int `json:"defaultReasons"`
+}
+
+func newReport() *report {
+	return &report{featuresByKey: make(map[string]*featureReport)}
+}
+
+// add counts the feature evaluations for a single user.
+func (r *report) add(evaluations []bucketing.FeatureEvaluation) {
+	r.Users++
+	for _, evaluation := range evaluations {
+		feature, ok := r.featuresByKey[evaluation.FeatureKey]
+		if !ok {
+			feature = &featureReport{
+				Key:            evaluation.FeatureKey,
+				Targets:        make(map[string]int),
+				Variations:     make(map[string]int),
+				DefaultReasons: make(map[string]int),
+			}
+			r.featuresByKey[evaluation.FeatureKey] = feature
+			r.Features = append(r.Features, feature)
+		}
+		if evaluation.TargetId == "" {
+			feature.DefaultReasons[string(evaluation.DefaultReason)]++
+			continue
+		}
+		feature.Served++
+		if evaluation.IsRollout {
+			feature.Rollout++
+		}
+		feature.Targets[evaluation.TargetId]++
+		feature.Variations[evaluation.VariationKey]++
+	}
+}
+
+func (r *report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *report) writeText(w io.Writer) {
+	fmt.Fprintf(w, "%d users\n", r.Users)
+	for _, feature := range r.Features {
+		fmt.Fprintf(w, "\nfeature %s\n", feature.Key)
+		fmt.Fprintf(w, "  served: %s\n", r.percentage(feature.Served))
+		fmt.Fprintf(w, "  rollout: %s\n", r.percentage(feature.Rollout))
+		r.writeCounts(w, "target", feature.Targets)
+		r.writeCounts(w, "variation", feature.Variations)
+		r.writeCounts(w, "default", feature.DefaultReasons)
+	}
+}
+
+func (r *report) writeCounts(w io.Writer, label string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s %s: %s\n", label, key, r.percentage(counts[key]))
+	}
+}
+
+func (r *report) percentage(count int) string {
+	if r.Users == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%.2f%%)", count, 100*float64(count)/float64(r.Users))
+}
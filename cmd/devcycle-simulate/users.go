@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+const (
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// forEachUser calls fn with every user read from r in the given format.
+func forEachUser(r io.Reader, format string, fn func(user api.User) error) error {
+	switch format {
+	case formatNDJSON:
+		return forEachNDJSONUser(r, fn)
+	case formatCSV:
+		return forEachCSVUser(r, fn)
+	default:
+		return fmt.Errorf("unknown user format %q", format)
+	}
+}
+
+func forEachNDJSONUser(r io.Reader, fn func(user api.User) error) error {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for line := 1; ; line++ {
+		var user api.User
+		err := decoder.Decode(&user)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("user %d: %w", line, err)
+		}
+		if err = fn(user); err != nil {
+			return err
+		}
+	}
+}
+
+func forEachCSVUser(r io.Reader, fn func(user api.User) error) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var user api.User
+		for i, value := range record {
+			if value != "" {
+				setUserField(&user, header[i], value)
+			}
+		}
+		if err = fn(user); err != nil {
+			return err
+		}
+	}
+}
+
+// setUserField sets the user field with the given JSON name, or adds the value to the user's
+// custom data if there is no such field.
+func setUserField(user *api.User, name, value string) {
+	fields := map[string]*string{
+		"user_id":     &user.UserId,
+		"email":       &user.Email,
+		"name":        &user.Name,
+		"language":    &user.Language,
+		"country":     &user.Country,
+		"appVersion":  &user.AppVersion,
+		"appBuild":    &user.AppBuild,
+		"deviceModel": &user.DeviceModel,
+		"ip":          &user.IP,
+	}
+	if field, ok := fields[name]; ok {
+		*field = value
+		return
+	}
+	if user.CustomData == nil {
+		user.CustomData = make(map[string]interface{})
+	}
+	user.CustomData[name] = parseCustomDataValue(value)
+}
+
+// parseCustomDataValue converts CSV values to the types custom data filters compare against. Only
+// the literals "true" and "false" are booleans, so numeric values like "1" stay numbers.
+func parseCustomDataValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
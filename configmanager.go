@@ -24,6 +24,12 @@ type ConfigReceiver interface {
 	HasConfig() bool
 }
 
+// UnknownFilterReporter is implemented by config receivers that can report the filters of the
+// stored config with a type the SDK doesn't support.
+type UnknownFilterReporter interface {
+	UnknownFilters() []UnknownFilterOccurrence
+}
+
 type EnvironmentConfigManager struct {
 	sdkKey               string
 	minimalConfig        *api.MinimalConfig
@@ -400,6 +406,34 @@ func (e *EnvironmentConfigManager) setConfig(config []byte, eTag, rayId, lastMod
 		return err
 	}
 
+	if reporter, ok := e.localBucketing.(UnknownFilterReporter); ok && e.options.ClientEventHandler != nil {
+		if unknownFilters := reporter.UnknownFilters(); len(unknownFilters) > 0 {
+			unknownFiltersEvent := api.ClientEvent{
+				EventType: api.ClientEventType_UnknownFilters,
+				EventData: unknownFilters,
+				Status:    "success",
+			}
+			go func() {
+				e.options.ClientEventHandler <- unknownFiltersEvent
+			}()
+		}
+	}
+
+	if catalog, ok := e.localBucketing.(ConfigCatalog); ok && e.options.FlagRegistry != nil && e.options.ClientEventHandler != nil {
+		if variables, catalogErr := catalog.VariablesCatalog(); catalogErr != nil {
+			util.Warnf("Failed to verify flag registry: %s", catalogErr)
+		} else if report := e.options.FlagRegistry.report(variables); report.HasProblems() {
+			registryEvent := api.ClientEvent{
+				EventType: api.ClientEventType_FlagRegistryProblems,
+				EventData: report,
+				Status:    "success",
+			}
+			go func() {
+				e.options.ClientEventHandler <- registryEvent
+			}()
+		}
+	}
+
 	err = json.Unmarshal(e.GetRawConfig(), &e.minimalConfig)
 	if err != nil {
 		configUpdatedEvent.EventType = api.ClientEventType_Error
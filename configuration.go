@@ -45,6 +45,19 @@ type Options struct {
 	BucketingAPIURI           string
 	Logger                    util.Logger
 	EvalHooks                 []*EvalHook
+	// AssignmentStore enables sticky bucketing for split targets when using local bucketing
+	AssignmentStore AssignmentStore
+	// Clock is used for evaluation, event timestamps and event flushing. Defaults to the system clock.
+	Clock Clock
+	// UnknownFilterPolicy decides how config filters this SDK doesn't support are evaluated when
+	// using local bucketing. Defaults to UnknownFilterFailClosed.
+	UnknownFilterPolicy UnknownFilterPolicy
+	// QuarantineInvalidFeatures loads configs without their invalid features when using local
+	// bucketing, instead of rejecting the whole config. See Client.QuarantinedFeatures.
+	QuarantineInvalidFeatures bool
+	// FlagRegistry is verified against each config loaded with local bucketing. Problems are sent
+	// as a ClientEventType_FlagRegistryProblems event. See Client.RegistryReport.
+	FlagRegistry *FlagRegistry
 	AdvancedOptions
 
 	configMetadata ConfigMetadata
@@ -59,9 +72,17 @@ func (o *Options) eventQueueOptions() *EventQueueOptions {
 		FlushEventQueueSize:          o.FlushEventQueueSize,
 		EventRequestChunkSize:        100, // TODO: make this configurable
 		EventsAPIBasePath:            o.EventsAPIURI,
+		Clock:                        o.clock(),
 	}
 }
 
+func (o *Options) clock() Clock {
+	if o.Clock == nil {
+		return api.SystemClock{}
+	}
+	return o.Clock
+}
+
 func (o *Options) CheckDefaults() {
 	if o.ConfigCDNURI == "" {
 		o.ConfigCDNURI = "https://config-cdn.devcycle.com"
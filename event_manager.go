@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"os"
 	"sync"
-	"time"
 
 	"github.com/devcyclehq/go-server-sdk/v2/util"
 )
@@ -65,12 +64,12 @@ func NewEventManager(options *Options, localBucketing InternalEventQueue, cfg *H
 		return e, nil
 	}
 
-	ticker := time.NewTicker(e.options.EventFlushIntervalMS)
+	ticker := e.options.clock().NewTicker(e.options.EventFlushIntervalMS)
 
 	go func() {
 		for {
 			select {
-			case <-ticker.C:
+			case <-ticker.C():
 				err := e.FlushEvents()
 				if err != nil {
 					util.Warnf("Error flushing primary events queue: %s\n", err)
@@ -126,7 +125,7 @@ func (e *EventManager) QueueSDKConfigEvent(req http.Request, resp http.Response)
 	user := api.User{UserId: fmt.Sprintf("%s@%s", uuid, hostname)}
 
 	event := api.Event{
-		ClientDate: time.Now(),
+		ClientDate: e.options.clock().Now(),
 		Type_:      api.EventType_SDKConfig,
 		UserId:     user.UserId,
 		Target:     fmt.Sprintf("%s://%s%s", req.URL.Scheme, req.URL.Host, req.URL.Path),
@@ -144,6 +143,15 @@ func (e *EventManager) QueueSDKConfigEvent(req http.Request, resp http.Response)
 			"errMsg":           resp.Status,
 		},
 	}
+	if reporter, ok := e.internalQueue.(UnknownFilterReporter); ok {
+		if unknownFilters := reporter.UnknownFilters(); len(unknownFilters) > 0 {
+			unknownFilterTypes := make([]string, len(unknownFilters))
+			for i, unknownFilter := range unknownFilters {
+				unknownFilterTypes[i] = unknownFilter.Type
+			}
+			event.MetaData["unknownFilterTypes"] = unknownFilterTypes
+		}
+	}
 	// We don't actually care about this failing or succeeding. It's best effort to send the event.
 	return e.QueueEvent(user, event)
 }
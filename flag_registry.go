@@ -0,0 +1,257 @@
+package devcycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/devcyclehq/go-server-sdk/v2/bucketing"
+)
+
+// Flag declares a variable with its key, default and description, so they aren't repeated at
+// every call site. Register flags with a FlagRegistry to verify them against the config.
+type Flag[T any] struct {
+	Key         string
+	Default     T
+	Description string
+}
+
+// Get evaluates the flag for user data, returning its default if the variable is defaulted or its
+// value can't be represented exactly as T, e.g. 1.7 for an int. Flags of struct, map or slice types are evaluated as JSON
+// variables, see Client.JSONVariableInto.
+func (f Flag[T]) Get(c *Client, userdata User) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if flagVariableType(t) == bucketing.VariableTypesJSON {
+		return GetJSON(c, userdata, f.Key, f.Default)
+	}
+
+	defaultValue := reflect.ValueOf(&f.Default).Elem()
+	var variableDefault interface{}
+	switch t.Kind() {
+	case reflect.Bool:
+		variableDefault = defaultValue.Bool()
+	case reflect.String:
+		variableDefault = defaultValue.String()
+	default:
+		variableDefault = defaultValue.Convert(reflect.TypeOf(float64(0))).Float()
+	}
+	variable, err := c.Variable(userdata, f.Key, variableDefault)
+	if err != nil {
+		return f.Default, err
+	}
+	value, ok := convertFlagValue(reflect.ValueOf(variable.Value), t)
+	if !ok {
+		return f.Default, nil
+	}
+	return value.Interface().(T), nil
+}
+
+// convertFlagValue converts a variable value to t if it can be represented exactly, other than
+// rounding numbers to float32.
+func convertFlagValue(value reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	if !value.IsValid() {
+		return reflect.Value{}, false
+	}
+	if value.Kind() != reflect.Float64 {
+		if value.Kind() != t.Kind() || !value.Type().ConvertibleTo(t) {
+			return reflect.Value{}, false
+		}
+		return value.Convert(t), true
+	}
+
+	f := value.Float()
+	converted := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || converted.OverflowInt(int64(f)) {
+			return reflect.Value{}, false
+		}
+		converted.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || converted.OverflowUint(uint64(f)) {
+			return reflect.Value{}, false
+		}
+		converted.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		if converted.OverflowFloat(f) {
+			return reflect.Value{}, false
+		}
+		converted.SetFloat(f)
+	default:
+		return reflect.Value{}, false
+	}
+	return converted, true
+}
+
+// RegisteredFlag is a flag registered with a FlagRegistry.
+type RegisteredFlag struct {
+	Key string `json:"key"`
+	// Type is the variable type the flag's Go type is evaluated as
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Description string      `json:"description,omitempty"`
+}
+
+// variableDefault returns the flag's default as the value of its variable type, so that defaults
+// of different Go types are compared as they are evaluated, e.g. int(10) and int64(10).
+func (f RegisteredFlag) variableDefault() interface{} {
+	value := reflect.ValueOf(f.Default)
+	if !value.IsValid() {
+		return nil
+	}
+	switch f.Type {
+	case bucketing.VariableTypesBool:
+		return value.Bool()
+	case bucketing.VariableTypesString:
+		return value.String()
+	case bucketing.VariableTypesNumber:
+		return value.Convert(reflect.TypeOf(float64(0))).Float()
+	}
+	var decoded interface{}
+	if data, err := json.Marshal(f.Default); err == nil && json.Unmarshal(data, &decoded) == nil {
+		return decoded
+	}
+	return f.Default
+}
+
+// FlagTypeMismatch is a registered flag whose type doesn't match its variable in the config.
+type FlagTypeMismatch struct {
+	Key          string `json:"key"`
+	FlagType     string `json:"flagType"`
+	VariableType string `json:"variableType"`
+}
+
+// FlagConflict is a key registered more than once with different types or defaults.
+type FlagConflict struct {
+	Key   string           `json:"key"`
+	Flags []RegisteredFlag `json:"flags"`
+}
+
+// RegistryReport lists the problems found verifying a FlagRegistry against a config.
+type RegistryReport struct {
+	// Missing are the keys of registered flags without a variable in the config
+	Missing             []string           `json:"missing,omitempty"`
+	TypeMismatches      []FlagTypeMismatch `json:"typeMismatches,omitempty"`
+	ConflictingDefaults []FlagConflict     `json:"conflictingDefaults,omitempty"`
+}
+
+func (r RegistryReport) HasProblems() bool {
+	return len(r.Missing) > 0 || len(r.TypeMismatches) > 0 || len(r.ConflictingDefaults) > 0
+}
+
+// FlagRegistry holds the flags an application uses, to be verified against the config by setting
+// Options.FlagRegistry. It is safe for concurrent use.
+type FlagRegistry struct {
+	mu    sync.Mutex
+	flags map[string][]RegisteredFlag
+}
+
+func NewFlagRegistry() *FlagRegistry {
+	return &FlagRegistry{flags: make(map[string][]RegisteredFlag)}
+}
+
+// Register adds a flag to the registry and returns it, so flags can be declared as
+//
+//	var newCheckout = devcycle.Register(registry, devcycle.Flag[bool]{Key: "new-checkout"})
+//
+// Registering a key again with the same type and default has no effect.
+func Register[T any](r *FlagRegistry, flag Flag[T]) Flag[T] {
+	registered := RegisteredFlag{
+		Key:         flag.Key,
+		Type:        flagVariableType(reflect.TypeOf((*T)(nil)).Elem()),
+		Default:     flag.Default,
+		Description: flag.Description,
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.flags[flag.Key] {
+		if existing.Type == registered.Type && reflect.DeepEqual(existing.variableDefault(), registered.variableDefault()) {
+			return flag
+		}
+	}
+	r.flags[flag.Key] = append(r.flags[flag.Key], registered)
+	return flag
+}
+
+// Flags returns the registered flags ordered by key. Keys registered with conflicting types or
+// defaults are listed once for each registration.
+func (r *FlagRegistry) Flags() []RegisteredFlag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var flags []RegisteredFlag
+	for _, key := range r.sortedKeys() {
+		flags = append(flags, r.flags[key]...)
+	}
+	return flags
+}
+
+// report verifies the registered flags against the variables of a config.
+func (r *FlagRegistry) report(variables []VariableDescriptor) RegistryReport {
+	variableTypes := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		variableTypes[variable.Key] = variable.Type
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var report RegistryReport
+	for _, key := range r.sortedKeys() {
+		flags := r.flags[key]
+		if len(flags) > 1 {
+			report.ConflictingDefaults = append(report.ConflictingDefaults, FlagConflict{Key: key, Flags: append([]RegisteredFlag(nil), flags...)})
+		}
+		variableType, ok := variableTypes[key]
+		if !ok {
+			report.Missing = append(report.Missing, key)
+			continue
+		}
+		reported := make(map[string]bool)
+		for _, flag := range flags {
+			if flag.Type != variableType && !reported[flag.Type] {
+				reported[flag.Type] = true
+				report.TypeMismatches = append(report.TypeMismatches, FlagTypeMismatch{Key: key, FlagType: flag.Type, VariableType: variableType})
+			}
+		}
+	}
+	return report
+}
+
+func (r *FlagRegistry) sortedKeys() []string {
+	keys := make([]string, 0, len(r.flags))
+	for key := range r.flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flagVariableType returns the variable type values of type t are evaluated as.
+func flagVariableType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return bucketing.VariableTypesBool
+	case reflect.String:
+		return bucketing.VariableTypesString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return bucketing.VariableTypesNumber
+	}
+	return bucketing.VariableTypesJSON
+}
+
+// RegistryReport verifies the flags of Options.FlagRegistry against the current config.
+// Returns error for cloud SDK, when no registry is set or when config is not available
+func (c *Client) RegistryReport() (RegistryReport, error) {
+	if c.DevCycleOptions.FlagRegistry == nil {
+		return RegistryReport{}, fmt.Errorf("registry report not available - no flag registry set")
+	}
+	variables, err := c.VariablesCatalog()
+	if err != nil {
+		return RegistryReport{}, err
+	}
+	return c.DevCycleOptions.FlagRegistry.report(variables), nil
+}
@@ -0,0 +1,126 @@
+package devcycle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+func TestFlagRegistry_Report(t *testing.T) {
+	registry := NewFlagRegistry()
+	Register(registry, Flag[bool]{Key: "enabled", Default: false})
+	Register(registry, Flag[bool]{Key: "enabled", Default: false})
+	Register(registry, Flag[int]{Key: "limit", Default: 10})
+	Register(registry, Flag[int]{Key: "limit", Default: 20})
+	Register(registry, Flag[int64]{Key: "limit", Default: 10})
+	Register(registry, Flag[string]{Key: "color", Default: "red"})
+	Register(registry, Flag[jsonVariableMessage]{Key: "message"})
+	Register(registry, Flag[string]{Key: "missing"})
+
+	report := registry.report([]VariableDescriptor{
+		{Key: "enabled", Type: "Boolean"},
+		{Key: "limit", Type: "Number"},
+		{Key: "color", Type: "Number"},
+		{Key: "message", Type: "JSON"},
+	})
+	require.True(t, report.HasProblems())
+	require.Equal(t, RegistryReport{
+		Missing:        []string{"missing"},
+		TypeMismatches: []FlagTypeMismatch{{Key: "color", FlagType: "String", VariableType: "Number"}},
+		ConflictingDefaults: []FlagConflict{{Key: "limit", Flags: []RegisteredFlag{
+			{Key: "limit", Type: "Number", Default: 10},
+			{Key: "limit", Type: "Number", Default: 20},
+		}}},
+	}, report)
+	require.Len(t, registry.Flags(), 6, "registering the same flag twice, or with the same variable default, has no effect")
+
+	registry = NewFlagRegistry()
+	Register(registry, Flag[float64]{Key: "ratio", Default: 0.5})
+	require.False(t, registry.report([]VariableDescriptor{{Key: "ratio", Type: "Number"}}).HasProblems())
+}
+
+func TestConvertFlagValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		t     reflect.Type
+		want  interface{}
+	}{
+		{name: "int", value: 10.0, t: reflect.TypeOf(0), want: 10},
+		{name: "fractional int", value: 1.7, t: reflect.TypeOf(0)},
+		{name: "int8 overflow", value: 200.0, t: reflect.TypeOf(int8(0))},
+		{name: "negative uint", value: -1.0, t: reflect.TypeOf(uint(0))},
+		{name: "uint", value: 3.0, t: reflect.TypeOf(uint(0)), want: uint(3)},
+		{name: "float32", value: 0.5, t: reflect.TypeOf(float32(0)), want: float32(0.5)},
+		{name: "float32 overflow", value: 1e300, t: reflect.TypeOf(float32(0))},
+		{name: "string", value: "a", t: reflect.TypeOf(""), want: "a"},
+		{name: "number to string", value: 65.0, t: reflect.TypeOf("")},
+		{name: "bool to int", value: true, t: reflect.TypeOf(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, ok := convertFlagValue(reflect.ValueOf(tt.value), tt.t)
+			require.Equal(t, tt.want != nil, ok)
+			if ok {
+				require.Equal(t, tt.want, converted.Interface())
+			}
+		})
+	}
+}
+
+func TestClient_FlagRegistry(t *testing.T) {
+	registry := NewFlagRegistry()
+	enabled := Register(registry, Flag[bool]{Key: "test", Default: false, Description: "Enables the test"})
+	number := Register(registry, Flag[int]{Key: "test-number-variable", Default: 1})
+	message := Register(registry, Flag[jsonVariableMessage]{Key: "test-json-variable", Default: jsonVariableMessage{Message: "default"}})
+	Register(registry, Flag[bool]{Key: "test-string-variable"})
+	Register(registry, Flag[bool]{Key: "missing-variable"})
+
+	sdkKey, _ := httpConfigMock(200)
+	clientEventHandler := make(chan api.ClientEvent, 10)
+	c, err := NewClient(sdkKey, &Options{FlagRegistry: registry, ClientEventHandler: clientEventHandler})
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+
+	wantReport := RegistryReport{
+		Missing:        []string{"missing-variable"},
+		TypeMismatches: []FlagTypeMismatch{{Key: "test-string-variable", FlagType: "Boolean", VariableType: "String"}},
+	}
+	initialized, reported := false, false
+	for !initialized || !reported {
+		select {
+		case event := <-clientEventHandler:
+			switch event.EventType {
+			case api.ClientEventType_Initialized:
+				initialized = true
+			case api.ClientEventType_FlagRegistryProblems:
+				require.Equal(t, wantReport, event.EventData)
+				reported = true
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected initialized and flag registry problems events")
+		}
+	}
+
+	report, err := c.RegistryReport()
+	require.NoError(t, err)
+	require.Equal(t, wantReport, report)
+
+	user := User{UserId: "j_test", DeviceModel: "testing"}
+	enabledValue, err := enabled.Get(c, user)
+	require.NoError(t, err)
+	require.True(t, enabledValue)
+	numberValue, err := number.Get(c, user)
+	require.NoError(t, err)
+	require.Equal(t, 123, numberValue)
+	truncated, err := Flag[int]{Key: "test-float-variable", Default: 1}.Get(c, user)
+	require.NoError(t, err)
+	require.Equal(t, 1, truncated, "values that can't be represented exactly are defaulted")
+	messageValue, err := message.Get(c, user)
+	require.NoError(t, err)
+	require.Equal(t, jsonVariableMessage{Message: "a"}, messageValue)
+}
@@ -0,0 +1,148 @@
+package devcycle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrInvalidJSONDestination = errors.New("the destination for a JSON variable must be a non-nil pointer")
+
+var jsonVariableValidator = validator.New()
+
+// JSONSchema validates the values of a JSON variable before they are decoded, e.g. by adapting a
+// JSON Schema library. Values are passed as decoded by encoding/json into an interface{}.
+type JSONSchema interface {
+	Validate(value interface{}) error
+}
+
+// JSONSchemaFunc adapts a function to the JSONSchema interface.
+type JSONSchemaFunc func(value interface{}) error
+
+func (f JSONSchemaFunc) Validate(value interface{}) error {
+	return f(value)
+}
+
+// RegisterJSONSchema registers the schema that values of the JSON variable with the given key must
+// match to be decoded by JSONVariableInto and GetJSON. A nil schema removes the registered schema.
+func (c *Client) RegisterJSONSchema(key string, schema JSONSchema) {
+	if schema == nil || isNilSchema(schema) {
+		c.jsonSchemas.Delete(key)
+		return
+	}
+	c.jsonSchemas.Store(key, schema)
+}
+
+// isNilSchema reports whether the schema is a typed nil, such as a nil JSONSchemaFunc.
+func isNilSchema(schema JSONSchema) bool {
+	value := reflect.ValueOf(schema)
+	switch value.Kind() {
+	case reflect.Func, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan:
+		return value.IsNil()
+	}
+	return false
+}
+
+/*
+JSONVariableInto - Decode the value of a JSON variable for user data into dst, which must be a
+non-nil pointer. The value dst points to is used as the default, and is left unchanged if the
+variable is defaulted. Values that can't be decoded into dst, that fail validation of dst's
+`validate` struct tags, or that don't match the schema registered for the key are defaulted with
+the "Invalid JSON Value" default reason. With local bucketing, they are reported as defaulted to
+hooks and events; with cloud bucketing, only to hooks.
+
+  - @param key Variable key
+
+  - @param dst Pointer to decode the variable value into
+
+    -@return Variable, with the value dst points to
+*/
+func (c *Client) JSONVariableInto(userdata User, key string, dst interface{}) (Variable, error) {
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Pointer || dstValue.IsNil() {
+		return Variable{}, ErrInvalidJSONDestination
+	}
+
+	// Values are decoded and validated before the evaluation is reported, so values that fail are
+	// reported as defaulted
+	var decoded reflect.Value
+	validate := func(value interface{}) error {
+		var err error
+		decoded, err = c.decodeJSONVariable(key, value, dstValue.Type().Elem())
+		return err
+	}
+	variable, err := c.variable(userdata, key, jsonDefaultValue(dst), validate)
+	if err != nil {
+		return variable, err
+	}
+	defaultValue := dstValue.Elem().Interface()
+	variable.DefaultValue = defaultValue
+	if variable.IsDefaulted || !decoded.IsValid() {
+		variable.Value = defaultValue
+		return variable, nil
+	}
+	dstValue.Elem().Set(decoded.Elem())
+	variable.Value = dstValue.Elem().Interface()
+	return variable, nil
+}
+
+// GetJSON decodes the value of a JSON variable for user data into a T, returning defaultValue if
+// the variable is defaulted. See Client.JSONVariableInto.
+func GetJSON[T any](c *Client, userdata User, key string, defaultValue T) (T, error) {
+	value := defaultValue
+	if _, err := c.JSONVariableInto(userdata, key, &value); err != nil {
+		return defaultValue, err
+	}
+	return value, nil
+}
+
+// jsonDefaultValue converts the value dst points to into a default for a JSON variable. Values
+// that aren't JSON objects, such as slices, are evaluated with an empty object as the default.
+func jsonDefaultValue(dst interface{}) map[string]interface{} {
+	var defaultValue map[string]interface{}
+	if data, err := json.Marshal(dst); err == nil {
+		_ = json.Unmarshal(data, &defaultValue)
+	}
+	if defaultValue == nil {
+		defaultValue = map[string]interface{}{}
+	}
+	return defaultValue
+}
+
+// decodeJSONVariable validates a JSON variable value against the schema registered for its key,
+// then decodes it into a new value of type t and validates its struct tags.
+func (c *Client) decodeJSONVariable(key string, value interface{}, t reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	// Validate the value as it appears in the config, not as the SDK decoded it
+	var raw interface{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return reflect.Value{}, err
+	}
+	if schema, ok := c.jsonSchemas.Load(key); ok {
+		if err = schema.(JSONSchema).Validate(raw); err != nil {
+			return reflect.Value{}, fmt.Errorf("value does not match schema: %w", err)
+		}
+	}
+
+	decoded := reflect.New(t)
+	if err = json.Unmarshal(data, decoded.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	// Validate struct tags of struct destinations, including pointers to structs
+	toValidate := decoded
+	if t.Kind() == reflect.Pointer {
+		toValidate = decoded.Elem()
+	}
+	if !toValidate.IsNil() && toValidate.Elem().Kind() == reflect.Struct {
+		if err = jsonVariableValidator.Struct(toValidate.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return decoded, nil
+}
@@ -0,0 +1,94 @@
+package devcycle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/devcyclehq/go-server-sdk/v2/api"
+)
+
+type jsonVariableMessage struct {
+	Message string `json:"message" validate:"required"`
+}
+
+type jsonVariableStrictMessage struct {
+	Message string `json:"message" validate:"oneof=b c"`
+}
+
+func TestClient_JSONVariableInto(t *testing.T) {
+	sdkKey, _ := httpConfigMock(200)
+	c, err := NewClient(sdkKey, &Options{})
+	require.NoError(t, err)
+	user := User{UserId: "j_test", DeviceModel: "testing"}
+
+	tests := []struct {
+		name        string
+		key         string
+		dst         interface{}
+		schema      JSONSchema
+		want        interface{}
+		wantDetails api.DefaultReason
+	}{
+		{name: "struct", key: "test-json-variable", dst: &jsonVariableMessage{Message: "default"}, want: jsonVariableMessage{Message: "a"}},
+		{name: "pointer to struct", key: "test-json-variable", dst: new(*jsonVariableMessage), want: &jsonVariableMessage{Message: "a"}},
+		{name: "map", key: "test-json-variable", dst: &map[string]string{}, want: map[string]string{"message": "a"}},
+		{name: "missing variable", key: "missing-json-variable", dst: &jsonVariableMessage{Message: "default"}, want: jsonVariableMessage{Message: "default"}, wantDetails: api.DefaultReasonMissingVariable},
+		{name: "undecodable value", key: "test-json-variable", dst: &[]string{"default"}, want: []string{"default"}, wantDetails: api.DefaultReasonInvalidJSONValue},
+		{name: "struct tag validation", key: "test-json-variable", dst: &jsonVariableStrictMessage{Message: "c"}, want: jsonVariableStrictMessage{Message: "c"}, wantDetails: api.DefaultReasonInvalidJSONValue},
+		{
+			name: "schema validation",
+			key:  "test-json-variable",
+			dst:  &jsonVariableMessage{Message: "default"},
+			schema: JSONSchemaFunc(func(value interface{}) error {
+				if _, ok := value.(map[string]interface{})["required"]; !ok {
+					return errors.New("missing required")
+				}
+				return nil
+			}),
+			want:        jsonVariableMessage{Message: "default"},
+			wantDetails: api.DefaultReasonInvalidJSONValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.RegisterJSONSchema(tt.key, tt.schema)
+			defer c.RegisterJSONSchema(tt.key, nil)
+
+			variable, err := c.JSONVariableInto(user, tt.key, tt.dst)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, variable.Value)
+			require.Equal(t, tt.wantDetails != "", variable.IsDefaulted)
+			if tt.wantDetails != "" {
+				require.Equal(t, string(tt.wantDetails), variable.Eval.Details)
+			}
+		})
+	}
+
+	_, err = c.JSONVariableInto(user, "test-json-variable", jsonVariableMessage{})
+	require.ErrorIs(t, err, ErrInvalidJSONDestination)
+
+	// A typed nil schema is removed like a nil schema
+	var nilSchema JSONSchemaFunc
+	c.RegisterJSONSchema("test-json-variable", nilSchema)
+	variable, err := c.JSONVariableInto(user, "test-json-variable", &jsonVariableMessage{})
+	require.NoError(t, err)
+	require.False(t, variable.IsDefaulted)
+
+	// Hooks see values that fail validation as defaulted
+	var hookVariables []api.Variable
+	c.AddHook(NewEvalHook(nil, nil, func(context *HookContext, variable *api.Variable, metadata *VariableMetadata) error {
+		hookVariables = append(hookVariables, *variable)
+		return nil
+	}, nil))
+	_, err = c.JSONVariableInto(user, "test-json-variable", &jsonVariableStrictMessage{Message: "c"})
+	require.NoError(t, err)
+	require.Len(t, hookVariables, 1)
+	require.True(t, hookVariables[0].IsDefaulted)
+	require.Equal(t, string(api.DefaultReasonInvalidJSONValue), hookVariables[0].Eval.Details)
+
+	message, err := GetJSON(c, user, "test-json-variable", jsonVariableMessage{Message: "default"})
+	require.NoError(t, err)
+	require.Equal(t, jsonVariableMessage{Message: "a"}, message)
+}
@@ -450,6 +450,8 @@ func createUserFromFlattenedContext(evalCtx openfeature.FlattenedContext) (User,
 				user.AppBuild = value
 			} else if key == "deviceModel" {
 				user.DeviceModel = value
+			} else if key == "ip" {
+				user.IP = value
 			} else if key == openfeature.TargetingKey || key == DEVCYCLE_USER_ID_KEY || key == DEVCYCLE_USER_ID_UNDERSCORE_KEY {
 				// Always skip the three userId sources
 			} else {
@@ -70,6 +70,7 @@ func TestOFcreateUserFromFlattenedContext_AllUserProperties(t *testing.T) {
 		"appVersion":  "1.0.0",
 		"appBuild":    "1",
 		"deviceModel": "iPhone X21",
+		"ip":          "192.168.1.1",
 	}
 	user, err := createUserFromFlattenedContext(ctx)
 	require.NoError(t, err)
@@ -81,6 +82,7 @@ func TestOFcreateUserFromFlattenedContext_AllUserProperties(t *testing.T) {
 	require.Equal(t, ctx["appVersion"], user.AppVersion)
 	require.Equal(t, ctx["appBuild"], user.AppBuild)
 	require.Equal(t, ctx["deviceModel"], user.DeviceModel)
+	require.Equal(t, ctx["ip"], user.IP)
 	require.Nil(t, user.CustomData)
 	require.Nil(t, user.PrivateCustomData)
 }